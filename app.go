@@ -1,6 +1,7 @@
 package tent
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"net/http"
@@ -60,12 +61,17 @@ const TokenTypeHawk = "https://tent.io/oauth/hawk-token"
 func oauthTokenURL(server *MetaPostServer) string { return server.URLs.OAuthToken }
 
 func (client *Client) RequestAccessToken(code string) (*hawk.Credentials, error) {
+	return client.RequestAccessTokenContext(context.Background(), code)
+}
+
+func (client *Client) RequestAccessTokenContext(ctx context.Context, code string) (*hawk.Credentials, error) {
 	data, _ := json.Marshal(&AccessTokenRequest{TokenType: TokenTypeHawk, Code: code})
 	tokenRes := &AccessTokenResponse{}
 	header := make(http.Header)
 	header.Set("Accept", "application/json")
 	header.Set("Content-Type", "application/json")
-	_, err := client.requestJSON("POST", oauthTokenURL, header, data, tokenRes)
+	ctx = withEndpoint(ctx, EndpointOAuthToken)
+	_, err := client.requestJSON(ctx, "POST", oauthTokenURL, header, data, tokenRes)
 	if err != nil {
 		return nil, err
 	}
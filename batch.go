@@ -0,0 +1,271 @@
+package tent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/tent/http-link-go"
+)
+
+const MediaTypeBatch = "application/vnd.tent.batch.v0+json"
+
+// A Batch queues post reads, creates and deletes so that they can be sent to
+// the server's batch endpoint in a single request. If the server doesn't have
+// a batch endpoint, the operations are performed one at a time. Posts with new
+// attachments can't be batched, so they are always created individually.
+type Batch struct {
+	client *Client
+	ops    []*batchOp
+}
+
+// A BatchResult holds the outcome of one batch operation. Post is the post
+// that was read or created, or the delete post returned by a delete.
+type BatchResult struct {
+	Post *Post
+	Refs []Post
+	Err  error
+}
+
+type batchOp struct {
+	method string
+
+	entity, id, version string
+	createDeletePost    bool
+
+	post *Post
+}
+
+func (client *Client) Batch() *Batch {
+	return &Batch{client: client}
+}
+
+func (b *Batch) GetPost(entity, id, version string) *Batch {
+	b.ops = append(b.ops, &batchOp{method: "GET", entity: entity, id: id, version: version})
+	return b
+}
+
+func (b *Batch) CreatePost(post *Post) *Batch {
+	b.ops = append(b.ops, &batchOp{method: "POST", post: post})
+	return b
+}
+
+func (b *Batch) DeletePost(id, version string, createDeletePost bool) *Batch {
+	b.ops = append(b.ops, &batchOp{method: "DELETE", id: id, version: version, createDeletePost: createDeletePost})
+	return b
+}
+
+func (b *Batch) Len() int { return len(b.ops) }
+
+type batchRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+type batchResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// batchItem is an operation prepared for sending in a batch request.
+type batchItem struct {
+	index  int
+	method string
+	url    urlFunc
+	header http.Header
+	body   []byte
+	post   *Post
+}
+
+// Send performs the queued operations and returns their results in the order
+// they were added. The returned error is only set if the batch request itself
+// failed, in which case none of the operations were performed.
+func (b *Batch) Send(ctx context.Context) ([]*BatchResult, error) {
+	results := make([]*BatchResult, len(b.ops))
+	var items []*batchItem
+	for i, op := range b.ops {
+		if op.method == "POST" && op.post.hasNewAttachments() {
+			continue
+		}
+		item, err := b.client.prepareBatchOp(op)
+		if err != nil {
+			results[i] = &BatchResult{Err: err}
+			continue
+		}
+		item.index = i
+		items = append(items, item)
+	}
+
+	if len(items) > 0 {
+		if err := b.client.sendBatch(ctx, items, results); err != nil {
+			return nil, err
+		}
+	}
+	for i, op := range b.ops {
+		if results[i] == nil {
+			results[i] = b.client.doBatchOp(ctx, op)
+		}
+	}
+	return results, nil
+}
+
+func (client *Client) prepareBatchOp(op *batchOp) (*batchItem, error) {
+	item := &batchItem{method: op.method, header: make(http.Header)}
+	switch op.method {
+	case "GET":
+		item.header.Set("Accept", MediaTypePost)
+		item.url = func(server *MetaPostServer) string { return server.URLs.PostURL(op.entity, op.id, op.version) }
+		item.post = &Post{}
+	case "POST":
+		if err := client.validateNewPost(op.post); err != nil {
+			return nil, err
+		}
+		// work on a copy, as the post is sent unchanged if the batch
+		// falls back to individual requests
+		post := *op.post
+		item.post = op.post
+		item.method, item.url = client.postCreateURL(&post)
+		item.header.Set("Content-Type", post.contentType())
+		if len(post.Links) > 0 {
+			item.header.Set("Link", link.Format(post.Links))
+		}
+		data, err := json.Marshal(&post)
+		if err != nil {
+			return nil, err
+		}
+		item.body = data
+	case "DELETE":
+		item.url = func(server *MetaPostServer) string { return server.URLs.PostURL(client.Entity, op.id, op.version) }
+		if !op.createDeletePost {
+			item.header.Set("Create-Delete-Post", "false")
+		}
+		item.post = &Post{}
+	}
+	return item, nil
+}
+
+// sendBatch sends items as a single batch request and fills in their results.
+// If the server has no batch endpoint, the results are left unset so that the
+// operations are performed individually.
+func (client *Client) sendBatch(ctx context.Context, items []*batchItem, results []*BatchResult) error {
+	ctx = withEndpoint(ctx, EndpointBatch)
+	idempotent := true
+	for _, item := range items {
+		idempotent = idempotent && idempotentMethod(item.method)
+	}
+	return client.request(ctx, idempotent, func(server *MetaPostServer) error {
+		if server.URLs.Batch == "" {
+			return nil
+		}
+		reqs := make([]batchRequest, len(items))
+		for i, item := range items {
+			reqs[i] = batchRequest{Method: item.method, URL: item.url(server), Body: item.body}
+			if len(item.header) > 0 {
+				reqs[i].Headers = make(map[string]string, len(item.header))
+				for k := range item.header {
+					reqs[i].Headers[k] = item.header.Get(k)
+				}
+			}
+		}
+		data, err := json.Marshal(map[string]interface{}{"requests": reqs})
+		if err != nil {
+			return err
+		}
+		header := make(http.Header)
+		header.Set("Content-Type", MediaTypeBatch)
+		header.Set("Accept", MediaTypeBatch)
+		req, err := client.NewRequestContext(ctx, "POST", server.URLs.Batch, header, data)
+		if err != nil {
+			return err
+		}
+		res, err := client.do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != 200 {
+			return newResponseError(ErrBadStatusCode, res)
+		}
+		var batchRes struct {
+			Responses []batchResponse `json:"responses"`
+		}
+		err = client.readBody(res, func(body io.Reader) error {
+			return json.NewDecoder(body).Decode(&batchRes)
+		})
+		if err != nil {
+			return err
+		}
+		if len(batchRes.Responses) != len(items) {
+			return newResponseError(ErrBadData, res)
+		}
+		for i, item := range items {
+			results[item.index] = client.batchResult(item, reqs[i], &batchRes.Responses[i])
+		}
+		return nil
+	})
+}
+
+func (client *Client) batchResult(item *batchItem, req batchRequest, res *batchResponse) *BatchResult {
+	// build a response for the operation so that errors look the same as they
+	// would if it had been performed individually
+	subReq := &http.Request{Method: req.Method, Header: item.header}
+	subReq.URL, _ = url.Parse(req.URL)
+	subRes := &http.Response{
+		Status:     strconv.Itoa(res.Status) + " " + http.StatusText(res.Status),
+		StatusCode: res.Status,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(bytes.NewReader(res.Body)),
+		Request:    subReq,
+	}
+	for k, v := range res.Headers {
+		subRes.Header.Set(k, v)
+	}
+	if res.Status != 200 {
+		return &BatchResult{Err: newResponseError(ErrBadStatusCode, subRes)}
+	}
+	env := &PostEnvelope{Post: item.post}
+	if err := json.Unmarshal(res.Body, env); err != nil || env.Post == nil {
+		return &BatchResult{Err: newResponseError(ErrBadData, subRes)}
+	}
+	if err := client.verifyEnvelope(env, nil); err != nil {
+		return &BatchResult{Err: err}
+	}
+	if linkHeader := subRes.Header.Get("Link"); linkHeader != "" && item.method != "GET" {
+		env.Post.Links, _ = link.Parse(linkHeader)
+	}
+	env.Post.initAttachments(client)
+	for i := range env.Refs {
+		env.Refs[i].initAttachments(client)
+	}
+	client.decodeEnvelopeContent(env)
+	return &BatchResult{Post: env.Post, Refs: env.Refs}
+}
+
+func (client *Client) doBatchOp(ctx context.Context, op *batchOp) *BatchResult {
+	switch op.method {
+	case "GET":
+		env, err := client.GetPostContext(ctx, op.entity, op.id, op.version, nil)
+		if err != nil {
+			return &BatchResult{Err: err}
+		}
+		return &BatchResult{Post: env.Post, Refs: env.Refs}
+	case "POST":
+		if err := client.CreatePostContext(ctx, op.post); err != nil {
+			return &BatchResult{Err: err}
+		}
+		return &BatchResult{Post: op.post}
+	default:
+		post, err := client.DeletePostContext(ctx, op.id, op.version, op.createDeletePost)
+		if err != nil {
+			return &BatchResult{Err: err}
+		}
+		return &BatchResult{Post: post}
+	}
+}
@@ -0,0 +1,67 @@
+package tent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+
+	. "launchpad.net/gocheck"
+)
+
+type BatchSuite struct{}
+
+var _ = Suite(&BatchSuite{})
+
+func (s *BatchSuite) TestBatch(c *C) {
+	var batches, posts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/batch" {
+			atomic.AddInt32(&posts, 1)
+			if strings.HasSuffix(r.URL.Path, "/missing") {
+				w.WriteHeader(404)
+				return
+			}
+			w.Write([]byte(testPost))
+			return
+		}
+		atomic.AddInt32(&batches, 1)
+		c.Assert(r.Header.Get("Content-Type"), Equals, MediaTypeBatch)
+		var req struct {
+			Requests []batchRequest `json:"requests"`
+		}
+		c.Assert(json.NewDecoder(r.Body).Decode(&req), IsNil)
+		c.Assert(req.Requests, HasLen, 3)
+		c.Assert(req.Requests[0].Method, Equals, "GET")
+		c.Assert(req.Requests[1].Method, Equals, "POST")
+		c.Assert(req.Requests[2].Method, Equals, "GET")
+		json.NewEncoder(w).Encode(map[string]interface{}{"responses": []batchResponse{
+			{Status: 200, Body: []byte(testPost)},
+			{Status: 200, Body: []byte(`{"post":{"id":"b","type":"https://tent.io/types/status/v0#"}}`)},
+			{Status: 404},
+		}})
+	}))
+	defer ts.Close()
+
+	for _, batchURL := range []string{ts.URL + "/batch", ""} {
+		client := testClient([]string{ts.URL})
+		client.Servers[0].URLs.Batch = batchURL
+		results, err := client.Batch().
+			GetPost("https://example.com", "a", "").
+			CreatePost(&Post{Type: "https://tent.io/types/status/v0#"}).
+			GetPost("https://example.com", "missing", "").
+			Send(context.Background())
+		c.Assert(err, IsNil)
+		c.Assert(results, HasLen, 3)
+		c.Assert(results[0].Err, IsNil)
+		c.Assert(results[0].Post.ID, Equals, "a")
+		c.Assert(results[1].Err, IsNil)
+		c.Assert(results[2].Err, NotNil)
+		c.Assert(errors.Is(results[2].Err, ErrNotFound), Equals, true)
+	}
+	c.Assert(atomic.LoadInt32(&batches), Equals, int32(1))
+	c.Assert(atomic.LoadInt32(&posts), Equals, int32(3))
+}
@@ -0,0 +1,44 @@
+package tent
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tent/hawk-go"
+)
+
+var ErrMissingCredentials = errors.New("tent: missing credentials")
+
+// SignedPostURL returns a URL for the post that can be fetched without Hawk
+// credentials until ttl has elapsed. The URL is authorized with a bewit created
+// from the client's credentials.
+func (client *Client) SignedPostURL(entity, id, version string, ttl time.Duration) (string, error) {
+	return client.signedURL(func(server *MetaPostServer) string {
+		return server.URLs.PostURL(entity, id, version)
+	}, ttl)
+}
+
+// SignedAttachmentURL returns a URL for the attachment that can be fetched
+// without Hawk credentials until ttl has elapsed.
+func (client *Client) SignedAttachmentURL(entity, digest string, ttl time.Duration) (string, error) {
+	return client.signedURL(func(server *MetaPostServer) string {
+		return server.URLs.AttachmentURL(entity, digest)
+	}, ttl)
+}
+
+func (client *Client) signedURL(urlFunc urlFunc, ttl time.Duration) (string, error) {
+	if client.Credentials == nil {
+		return "", ErrMissingCredentials
+	}
+	servers := client.orderedServers()
+	if len(servers) == 0 {
+		return "", ErrNoServers
+	}
+	u := urlFunc(&servers[0])
+	// the bewit timestamp is the expiry time
+	auth, err := hawk.NewURLAuth(u, client.Credentials, client.ClockOffset()+ttl)
+	if err != nil {
+		return "", err
+	}
+	return appendQuery(u, "bewit="+auth.Bewit()), nil
+}
@@ -0,0 +1,87 @@
+package tent
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/tent/hawk-go"
+	. "launchpad.net/gocheck"
+)
+
+type BewitSuite struct{}
+
+var _ = Suite(&BewitSuite{})
+
+var bewitCreds = &hawk.Credentials{ID: "id", Key: "secret", App: "app", Hash: sha256.New}
+
+func bewitClient() *Client {
+	return &Client{
+		Credentials: bewitCreds,
+		Servers: []MetaPostServer{{URLs: MetaPostServerURLs{
+			Post:       "https://example.com/posts/{entity}/{post}",
+			Attachment: "https://example.com/attachments/{entity}/{digest}",
+		}}},
+	}
+}
+
+func validateBewit(c *C, signed string) error {
+	req, err := http.NewRequest("GET", signed, nil)
+	c.Assert(err, IsNil)
+	// servers validate against the escaped request path
+	u, _ := url.Parse(signed)
+	req.URL.Path = u.EscapedPath()
+	auth, err := hawk.NewAuthFromRequest(req, func(creds *hawk.Credentials) error {
+		c.Assert(creds.ID, Equals, bewitCreds.ID)
+		creds.Key = bewitCreds.Key
+		creds.App = bewitCreds.App
+		creds.Hash = bewitCreds.Hash
+		return nil
+	}, nil)
+	c.Assert(err, IsNil)
+	return auth.Valid()
+}
+
+func (s *BewitSuite) TestSignedPostURL(c *C) {
+	signed, err := bewitClient().SignedPostURL("https://entity.example.com", "post1", "", time.Minute)
+	c.Assert(err, IsNil)
+	c.Assert(signed, Matches, `https://example.com/posts/https%3A%2F%2Fentity.example.com/post1\?bewit=.+`)
+	c.Assert(validateBewit(c, signed), IsNil)
+}
+
+func (s *BewitSuite) TestSignedPostVersionURL(c *C) {
+	signed, err := bewitClient().SignedPostURL("https://entity.example.com", "post1", "sha512t256-abc", time.Minute)
+	c.Assert(err, IsNil)
+	c.Assert(signed, Matches, `.*\?version=sha512t256-abc&bewit=.+`)
+	c.Assert(validateBewit(c, signed), IsNil)
+}
+
+func (s *BewitSuite) TestSignedAttachmentURL(c *C) {
+	signed, err := bewitClient().SignedAttachmentURL("https://entity.example.com", "sha512t256-def", time.Minute)
+	c.Assert(err, IsNil)
+	c.Assert(signed, Matches, `https://example.com/attachments/https%3A%2F%2Fentity.example.com/sha512t256-def\?bewit=.+`)
+	c.Assert(validateBewit(c, signed), IsNil)
+}
+
+func (s *BewitSuite) TestExpiredBewit(c *C) {
+	signed, err := bewitClient().SignedAttachmentURL("https://entity.example.com", "sha512t256-def", -time.Minute)
+	c.Assert(err, IsNil)
+	c.Assert(validateBewit(c, signed), Equals, hawk.ErrBewitExpired)
+}
+
+func (s *BewitSuite) TestTamperedBewitURL(c *C) {
+	signed, err := bewitClient().SignedPostURL("https://entity.example.com", "post1", "", time.Minute)
+	c.Assert(err, IsNil)
+	u, _ := url.Parse(signed)
+	u.RawPath = "/posts/https%3A%2F%2Fentity.example.com/post2"
+	u.Path = "/posts/https://entity.example.com/post2"
+	c.Assert(validateBewit(c, u.String()), Equals, hawk.ErrInvalidMAC)
+}
+
+func (s *BewitSuite) TestSignedURLWithoutCredentials(c *C) {
+	client := bewitClient()
+	client.Credentials = nil
+	_, err := client.SignedPostURL("https://entity.example.com", "post1", "", time.Minute)
+	c.Assert(err, Equals, ErrMissingCredentials)
+}
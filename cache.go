@@ -0,0 +1,185 @@
+package tent
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// A CachedResponse is a response body stored in a Cache along with the ETag
+// that is used to revalidate it.
+type CachedResponse struct {
+	ETag   string
+	Header http.Header
+	Body   []byte
+
+	// Immutable responses are served from the cache without revalidation.
+	Immutable bool
+}
+
+// A Cache stores responses to GET requests. Implementations must be safe for
+// concurrent use.
+type Cache interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, res *CachedResponse)
+	Delete(key string)
+}
+
+// WithCache caches post, meta post and post list responses in c. Cached
+// responses are revalidated with If-None-Match and served from the cache if
+// the server responds with 304 Not Modified. Posts requested with a specific
+// version never change, so they are served from the cache without a request.
+func WithCache(c Cache) ClientOption {
+	return func(client *Client) { client.cache = c }
+}
+
+type cacheableKey struct{}
+
+func withCacheable(ctx context.Context, immutable bool) context.Context {
+	return context.WithValue(ctx, cacheableKey{}, immutable)
+}
+
+func cacheable(req *http.Request) (immutable, ok bool) {
+	immutable, ok = req.Context().Value(cacheableKey{}).(bool)
+	return
+}
+
+// cachedBody is a response body that was verified before it was cached.
+type cachedBody struct{ *bytes.Reader }
+
+func (cachedBody) Close() error { return nil }
+
+func (c *CachedResponse) response(req *http.Request) *http.Response {
+	res := &http.Response{
+		Status:        "200 OK",
+		StatusCode:    200,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.Header.Clone(),
+		Body:          cachedBody{bytes.NewReader(c.Body)},
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+	if res.Header == nil {
+		res.Header = make(http.Header)
+	}
+	res.Header.Set("Content-Length", strconv.Itoa(len(c.Body)))
+	return res
+}
+
+func (client *Client) doCached(req *http.Request, immutable bool) (*http.Response, error) {
+	if req.Header.Get("If-None-Match") != "" {
+		// the caller is doing its own revalidation
+		return client.doShared(req)
+	}
+	key := flightKey(req)
+	cached, ok := client.cache.Get(key)
+	if ok && cached.Immutable {
+		client.cacheHits.Add(1)
+		return cached.response(req), nil
+	}
+	if ok && cached.ETag != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	res, err := client.doShared(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case res.StatusCode == 304 && ok && cached.ETag != "":
+		res.Body.Close()
+		if _, err := client.verifyResponseHeader(res); err != nil {
+			return nil, err
+		}
+		client.cacheHits.Add(1)
+		return cached.response(res.Request), nil
+	case res.StatusCode == 404 || res.StatusCode == 410:
+		client.cache.Delete(key)
+		return res, nil
+	case res.StatusCode != 200:
+		return res, nil
+	}
+	etag := res.Header.Get("Etag")
+	if etag == "" && !immutable {
+		return res, nil
+	}
+
+	defer res.Body.Close()
+	if err := client.verifyResponse(res); err != nil {
+		return nil, err
+	}
+	body, err := client.readAll(res)
+	if err != nil {
+		return nil, err
+	}
+	client.cache.Set(key, &CachedResponse{ETag: etag, Header: res.Header.Clone(), Body: body, Immutable: immutable})
+	res.Body = cachedBody{bytes.NewReader(body)}
+	return res, nil
+}
+
+// MemoryCache is an in-memory Cache that evicts the least recently used
+// response once it holds more than its maximum number of entries.
+type MemoryCache struct {
+	mtx   sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type memoryCacheEntry struct {
+	key string
+	res *CachedResponse
+}
+
+// NewMemoryCache returns a MemoryCache that holds at most size responses.
+func NewMemoryCache(size int) *MemoryCache {
+	return &MemoryCache{size: size, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *MemoryCache) Get(key string) (*CachedResponse, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(e)
+	return e.Value.(*memoryCacheEntry).res, true
+}
+
+func (c *MemoryCache) Set(key string, res *CachedResponse) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if e, ok := c.items[key]; ok {
+		e.Value.(*memoryCacheEntry).res = res
+		c.ll.MoveToFront(e)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&memoryCacheEntry{key: key, res: res})
+	for c.size > 0 && c.ll.Len() > c.size {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.items, e.Value.(*memoryCacheEntry).key)
+	}
+}
+
+func (c *MemoryCache) Delete(key string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.Remove(e)
+		delete(c.items, key)
+	}
+}
+
+func (c *MemoryCache) Len() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.ll.Len()
+}
@@ -0,0 +1,74 @@
+package tent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+
+	. "launchpad.net/gocheck"
+)
+
+type CacheSuite struct{}
+
+var _ = Suite(&CacheSuite{})
+
+func (s *CacheSuite) TestCache(c *C) {
+	var requests, notModified int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Query().Get("version") == "" {
+			w.Header().Set("Etag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				atomic.AddInt32(&notModified, 1)
+				w.WriteHeader(304)
+				return
+			}
+		}
+		w.Write([]byte(testPost))
+	}))
+	defer ts.Close()
+
+	client := testClient([]string{ts.URL}, WithCache(NewMemoryCache(10)))
+
+	for i := 0; i < 2; i++ {
+		post, err := client.GetPost("https://example.com", "a", "", nil)
+		c.Assert(err, IsNil)
+		c.Assert(post.Post.ID, Equals, "a")
+	}
+	c.Assert(atomic.LoadInt32(&requests), Equals, int32(2))
+	c.Assert(atomic.LoadInt32(&notModified), Equals, int32(1))
+
+	for i := 0; i < 2; i++ {
+		post, err := client.GetPost("https://example.com", "a", "b", nil)
+		c.Assert(err, IsNil)
+		c.Assert(post.Post.ID, Equals, "a")
+	}
+	c.Assert(atomic.LoadInt32(&requests), Equals, int32(3))
+	c.Assert(client.Stats().CacheHits, Equals, int64(2))
+}
+
+func (s *CacheSuite) TestMemoryCacheEviction(c *C) {
+	cache := NewMemoryCache(2)
+	cache.Set("a", &CachedResponse{ETag: "a"})
+	cache.Set("b", &CachedResponse{ETag: "b"})
+	cache.Get("a")
+	cache.Set("c", &CachedResponse{ETag: "c"})
+	_, ok := cache.Get("b")
+	c.Assert(ok, Equals, false)
+	_, ok = cache.Get("a")
+	c.Assert(ok, Equals, true)
+	c.Assert(cache.Len(), Equals, 2)
+}
+
+func (s *CacheSuite) TestDiskCache(c *C) {
+	cache, err := NewDiskCache(c.MkDir())
+	c.Assert(err, IsNil)
+	cache.Set("a", &CachedResponse{ETag: `"a"`, Body: []byte("body"), Header: http.Header{"Etag": {`"a"`}}})
+	res, ok := cache.Get("a")
+	c.Assert(ok, Equals, true)
+	c.Assert(res.ETag, Equals, `"a"`)
+	c.Assert(string(res.Body), Equals, "body")
+	cache.Delete("a")
+	_, ok = cache.Get("a")
+	c.Assert(ok, Equals, false)
+}
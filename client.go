@@ -2,14 +2,18 @@ package tent
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
 	"net"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/tent/hawk-go"
@@ -30,9 +34,31 @@ type Client struct {
 	Servers []MetaPostServer
 
 	Entity string
+
+	http      *http.Client
+	transport http.RoundTripper
+	timeout   time.Duration
+	userAgent string
+
+	retryPolicy     *RetryPolicy
+	health          *serverHealth
+	verifyResponses bool
+	clockOffset     atomic.Int64
+	middleware      []Middleware
+	hooks           Hooks
+	limiter         *rateLimiter
+	flights         *flightGroup
+	coalesced       atomic.Int64
+	cache           Cache
+	cacheHits       atomic.Int64
+	decodeContent   bool
+	verifyVersions  bool
+	validatePosts   bool
+	validateSchemas bool
+	detectForks     bool
 }
 
-func NewClient(credsPost *Post, metaContent []byte) (*Client, error) {
+func NewClient(credsPost *Post, metaContent []byte, opts ...ClientOption) (*Client, error) {
 	creds, err := ParseCredentials(credsPost)
 	if err != nil {
 		return nil, err
@@ -41,93 +67,169 @@ func NewClient(credsPost *Post, metaContent []byte) (*Client, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Client{Credentials: creds, Servers: meta.Servers, Entity: meta.Entity}, nil
+	client := &Client{Credentials: creds, Servers: meta.Servers, Entity: meta.Entity}
+	client.configure(opts)
+	return client, nil
+}
+
+// NewPublicClient returns a client without credentials or servers, which can be
+// used for discovery and fetching public posts by URL. Unlike a zero Client,
+// such as the one used by the package-level Discover, GetMetaPost and GetPost,
+// it tracks server health and applies the options.
+func NewPublicClient(opts ...ClientOption) *Client {
+	client := &Client{}
+	client.configure(opts)
+	return client
 }
 
 func (client *Client) CreatePost(post *Post) error {
+	return client.CreatePostContext(context.Background(), post)
+}
+
+func (client *Client) CreatePostContext(ctx context.Context, post *Post) error {
+	if err := client.validateNewPost(post); err != nil {
+		return err
+	}
 	defer post.initAttachments(client)
 	if post.hasNewAttachments() {
-		return client.createPostWithAttachments(post)
+		return client.createPostWithAttachments(ctx, post)
 	}
-	return client.createPost(post)
+	return client.createPost(ctx, post)
 }
 
-func (client *Client) createPostWithAttachments(post *Post) error {
-	method, uri := client.postCreateURL(post)
-	req, err := client.NewRequest(method, uri, nil, nil)
-	if err != nil {
-		return err
-	}
+func (client *Client) createPostWithAttachments(ctx context.Context, post *Post) error {
+	method, urlFunc := client.postCreateURL(post)
+	ctx = withEndpoint(ctx, postCreateEndpoint(method))
 
 	oldAttachments := make([]*PostAttachment, 0, len(post.Attachments))
 	newAttachments := make([]*PostAttachment, 0, len(post.Attachments))
+	offsets := make([]int64, 0, len(post.Attachments))
 	for _, att := range post.Attachments {
 		if att.Data != nil {
+			offset, err := att.Data.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return err
+			}
 			newAttachments = append(newAttachments, att)
+			offsets = append(offsets, offset)
 		} else {
 			oldAttachments = append(oldAttachments, att)
 		}
 	}
 	post.Attachments = oldAttachments
 
-	bodyReader, bodyWriter := io.Pipe()
-	postWriter := NewMultipartPostWriter(bodyWriter)
-	req.Header.Set("Content-Type", postWriter.ContentType())
-	req.Body = bodyReader
-	errChan := make(chan error, 1)
-	go func() {
-		defer bodyWriter.Close()
-		err = postWriter.WritePost(post)
+	return client.request(ctx, idempotentMethod(method), func(server *MetaPostServer) error {
+		req, err := client.NewRequestContext(ctx, method, urlFunc(server), nil, nil)
 		if err != nil {
-			errChan <- err
-			return
+			return err
 		}
-		for _, att := range newAttachments {
-			err = postWriter.WriteAttachment(att)
-			if err != nil {
-				errChan <- err
-				return
+
+		var contentType string
+		var bodyReader *io.PipeReader
+		var errChan chan error
+		var stops []func()
+		defer func() {
+			for _, stop := range stops {
+				stop()
 			}
+		}()
+		// GetBody rewinds the attachments and streams a new copy of the body so
+		// that the request can be retried or resent.
+		req.GetBody = func() (io.ReadCloser, error) {
+			for i, att := range newAttachments {
+				if _, err := att.Data.Seek(offsets[i], io.SeekStart); err != nil {
+					return nil, err
+				}
+			}
+			pr, pw := io.Pipe()
+			postWriter := NewMultipartPostWriter(pw)
+			if contentType == "" {
+				contentType = postWriter.ContentType()
+			} else {
+				_, params, _ := mime.ParseMediaType(contentType)
+				postWriter.m.SetBoundary(params["boundary"])
+			}
+			// unblock the writer if the request is abandoned before the body is consumed
+			stops = append(stops, afterDone(ctx, func() { pr.CloseWithError(ctx.Err()) }))
+			ch := make(chan error, 1)
+			go func() {
+				defer pw.Close()
+				err := postWriter.WritePost(post)
+				if err != nil {
+					ch <- err
+					return
+				}
+				for _, att := range newAttachments {
+					err = postWriter.WriteAttachment(att)
+					if err != nil {
+						ch <- err
+						return
+					}
+				}
+				ch <- postWriter.Close()
+			}()
+			bodyReader, errChan = pr, ch
+			return pr, nil
 		}
-		err = postWriter.Close()
-		errChan <- err
-	}()
+		if req.Body, err = req.GetBody(); err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", contentType)
 
-	res, err := HTTP.Do(req)
-	if err != nil {
-		return newRequestError(err, req)
-	}
-	if err = <-errChan; err != nil {
-		return newRequestError(err, req)
-	}
+		res, err := client.do(req)
+		if err != nil {
+			bodyReader.CloseWithError(err)
+			<-errChan
+			return err
+		}
+		if err = <-errChan; err != nil {
+			res.Body.Close()
+			return newRequestError(err, req)
+		}
 
-	return parsePostRes(post, res)
+		return client.parsePostRes(post, res)
+	})
 }
 
-func (client *Client) createPost(post *Post) error {
+// validateNewPost checks a post before it is created, if WithPostValidation or
+// WithSchemaValidation are set.
+func (client *Client) validateNewPost(post *Post) error {
+	switch {
+	case client.validatePosts:
+		return post.Validate()
+	case client.validateSchemas:
+		return post.ValidateContent()
+	}
+	return nil
+}
+
+func (client *Client) createPost(ctx context.Context, post *Post) error {
 	data, err := json.Marshal(post)
 	if err != nil {
 		return err
 	}
-	method, uri := client.postCreateURL(post)
+	method, urlFunc := client.postCreateURL(post)
+	ctx = withEndpoint(ctx, postCreateEndpoint(method))
 	header := make(http.Header)
 	header.Set("Content-Type", post.contentType())
 	if len(post.Links) > 0 {
 		header.Set("Link", link.Format(post.Links))
 		post.Links = nil
 	}
-	req, err := client.NewRequest(method, uri, header, data)
-	if err != nil {
-		return err
-	}
-	res, err := HTTP.Do(req)
-	if err != nil {
-		return newRequestError(err, req)
-	}
-	return parsePostRes(post, res)
+	return client.request(ctx, idempotentMethod(method), func(server *MetaPostServer) error {
+		req, err := client.NewRequestContext(ctx, method, urlFunc(server), header.Clone(), data)
+		if err != nil {
+			return err
+		}
+		res, err := client.do(req)
+		if err != nil {
+			return err
+		}
+		return client.parsePostRes(post, res)
+	})
 }
 
-func parsePostRes(post *Post, res *http.Response) error {
+func (client *Client) parsePostRes(post *Post, res *http.Response) error {
 	defer res.Body.Close()
 	if res.StatusCode != 200 {
 		return newResponseError(ErrBadStatusCode, res)
@@ -141,41 +243,52 @@ func parsePostRes(post *Post, res *http.Response) error {
 		post.Links = links
 	}
 
-	var err error
-	if ok := timeoutRead(res.Body, func() {
-		err = json.NewDecoder(res.Body).Decode(&PostEnvelope{Post: post})
-	}); !ok {
-		return newResponseError(ErrReadTimeout, res)
-	}
-	return err
+	return client.readBody(res, func(body io.Reader) error {
+		return json.NewDecoder(body).Decode(&PostEnvelope{Post: post})
+	})
 }
 
-func (client *Client) postCreateURL(post *Post) (method string, uri string) {
+func (client *Client) postCreateURL(post *Post) (method string, uri urlFunc) {
 	if post.ID == "" {
-		method, uri = "POST", client.Servers[0].URLs.NewPost
-	} else {
-		method = "PUT"
-		uri = client.Servers[0].URLs.PostURL(post.Entity, post.ID, "")
-		post.Entity = ""
-		post.ID = ""
+		return "POST", func(server *MetaPostServer) string { return server.URLs.NewPost }
 	}
-	return
+	entity, id := post.Entity, post.ID
+	post.Entity = ""
+	post.ID = ""
+	return "PUT", func(server *MetaPostServer) string { return server.URLs.PostURL(entity, id, "") }
+}
+
+func postCreateEndpoint(method string) string {
+	if method == "POST" {
+		return EndpointNewPost
+	}
+	return EndpointPost
 }
 
 func (client *Client) GetAttachment(entity, digest string) (body io.ReadCloser, header http.Header, err error) {
-	err = client.Request(func(server *MetaPostServer) error {
+	return client.GetAttachmentContext(context.Background(), entity, digest)
+}
+
+func (client *Client) GetAttachmentContext(ctx context.Context, entity, digest string) (body io.ReadCloser, header http.Header, err error) {
+	ctx = withEndpoint(ctx, EndpointAttachment)
+	err = client.RequestContext(ctx, func(server *MetaPostServer) error {
 		url := server.URLs.AttachmentURL(entity, digest)
-		req, err := client.NewRequest("GET", url, nil, nil)
+		req, err := client.NewRequestContext(ctx, "GET", url, nil, nil)
 		if err != nil {
 			return err
 		}
-		res, err := HTTP.Do(req)
+		res, err := client.do(req)
 		if err != nil {
-			return newRequestError(err, req)
+			return err
 		}
 		if res.StatusCode != 200 {
+			defer res.Body.Close()
 			return newResponseError(ErrBadStatusCode, res)
 		}
+		if err := client.verifyResponse(res); err != nil {
+			res.Body.Close()
+			return err
+		}
 		body = res.Body
 		header = res.Header
 		return nil
@@ -184,22 +297,32 @@ func (client *Client) GetAttachment(entity, digest string) (body io.ReadCloser,
 }
 
 func (client *Client) GetPostAttachment(entity, post, version, name, accept string) (body io.ReadCloser, header http.Header, err error) {
-	err = client.Request(func(server *MetaPostServer) error {
+	return client.GetPostAttachmentContext(context.Background(), entity, post, version, name, accept)
+}
+
+func (client *Client) GetPostAttachmentContext(ctx context.Context, entity, post, version, name, accept string) (body io.ReadCloser, header http.Header, err error) {
+	ctx = withEndpoint(ctx, EndpointPostAttachment)
+	err = client.RequestContext(ctx, func(server *MetaPostServer) error {
 		url := server.URLs.PostAttachmentURL(entity, post, version, name)
-		req, err := client.NewRequest("GET", url, nil, nil)
+		req, err := client.NewRequestContext(ctx, "GET", url, nil, nil)
+		if err != nil {
+			return err
+		}
 		if accept != "" {
 			req.Header.Set("Accept", accept)
 		}
+		res, err := client.do(req)
 		if err != nil {
 			return err
 		}
-		res, err := HTTP.Do(req)
-		if err != nil {
-			return newRequestError(err, req)
-		}
 		if res.StatusCode != 200 {
+			defer res.Body.Close()
 			return newResponseError(ErrBadStatusCode, res)
 		}
+		if err := client.verifyResponse(res); err != nil {
+			res.Body.Close()
+			return err
+		}
 		body = res.Body
 		header = res.Header
 		return nil
@@ -208,42 +331,109 @@ func (client *Client) GetPostAttachment(entity, post, version, name, accept stri
 }
 
 func (client *Client) DeletePost(id, version string, createDeletePost bool) (*Post, error) {
+	return client.DeletePostContext(context.Background(), id, version, createDeletePost)
+}
+
+func (client *Client) DeletePostContext(ctx context.Context, id, version string, createDeletePost bool) (*Post, error) {
+	ctx = withEndpoint(ctx, EndpointPost)
 	post := &Post{}
-	return post, client.Request(func(server *MetaPostServer) error {
+	return post, client.RequestContext(ctx, func(server *MetaPostServer) error {
 		url := server.URLs.PostURL(client.Entity, id, version)
 		header := make(http.Header)
 		if !createDeletePost {
 			header.Set("Create-Delete-Post", "false")
 		}
 
-		req, err := client.NewRequest("DELETE", url, header, nil)
+		req, err := client.NewRequestContext(ctx, "DELETE", url, header, nil)
 		if err != nil {
 			return err
 		}
-		res, err := HTTP.Do(req)
+		res, err := client.do(req)
 		if err != nil {
-			return newRequestError(err, req)
+			return err
 		}
-		return parsePostRes(post, res)
+		return client.parsePostRes(post, res)
 	})
 }
 
 func (client *Client) Request(req func(*MetaPostServer) error) error {
-	for i, server := range client.Servers {
+	return client.RequestContext(context.Background(), req)
+}
+
+// RequestContext calls req with each server in order of preference until one
+// succeeds, retrying transient failures according to the client's RetryPolicy.
+// Servers that recently failed are tried last. If more than one attempt fails,
+// the returned error is an *AttemptsError.
+func (client *Client) RequestContext(ctx context.Context, req func(*MetaPostServer) error) error {
+	return client.request(ctx, true, req)
+}
+
+var ErrNoServers = errors.New("tent: no servers to send the request to")
+
+func (client *Client) request(ctx context.Context, idempotent bool, req func(*MetaPostServer) error) error {
+	servers := client.orderedServers()
+	if len(servers) == 0 {
+		return ErrNoServers
+	}
+	policy := client.retry()
+	hooks := client.getHooks()
+	attempts := policy.attempts(len(servers))
+	if noRetries(ctx) && attempts > len(servers) {
+		attempts = len(servers)
+	}
+	var errs []error
+	for i := 0; i < attempts; i++ {
+		server := servers[i%len(servers)]
+		if i > 0 {
+			lastErr := errs[len(errs)-1]
+			var wait time.Duration
+			if i%len(servers) == 0 {
+				// every server has been tried, wait before starting over
+				wait = policy.backoff(i/len(servers) - 1)
+				if d, ok := retryAfter(lastErr); ok && d > wait {
+					if d > policy.maxRetryAfter() {
+						break
+					}
+					wait = d
+				}
+				hooks.Retry(i/len(servers), lastErr, wait)
+			}
+			if prev := servers[(i-1)%len(servers)]; prev.URLs != server.URLs {
+				hooks.ServerFailover(&prev, &server, lastErr)
+			}
+			if err := sleepContext(ctx, wait); err != nil {
+				errs = append(errs, err)
+				break
+			}
+		}
 		err := req(&server)
-		if err != nil && i < len(client.Servers)-1 {
-			continue
+		if err == nil {
+			client.health.success(&server)
+			return nil
 		}
-		return err
+		if retryable(err, true) {
+			client.health.failure(&server)
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil || !retryable(err, idempotent) {
+			break
+		}
+	}
+	if len(errs) == 1 {
+		return errs[0]
 	}
-	panic("not reached")
+	return &AttemptsError{Errors: errs}
 }
 
 func (client *Client) SignRequest(req *http.Request, body []byte) {
+	client.signRequest(req, body)
+}
+
+func (client *Client) signRequest(req *http.Request, body []byte) *hawk.Auth {
 	if client.Credentials == nil {
 		panic("tent: missing credentials")
 	}
-	auth := hawk.NewRequestAuth(req, client.Credentials, 0)
+	auth := hawk.NewRequestAuth(req, client.Credentials, client.ClockOffset())
 	if body != nil {
 		mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
 		h := auth.PayloadHash(mediaType)
@@ -251,63 +441,66 @@ func (client *Client) SignRequest(req *http.Request, body []byte) {
 		auth.SetHash(h)
 	}
 	req.Header.Set("Authorization", auth.RequestHeader())
+	return auth
 }
 
 func (client *Client) NewRequest(method, url string, header http.Header, body []byte) (*http.Request, error) {
+	return client.NewRequestContext(context.Background(), method, url, header, body)
+}
+
+func (client *Client) NewRequestContext(ctx context.Context, method, url string, header http.Header, body []byte) (*http.Request, error) {
 	var bodyReader io.Reader
 	if body != nil {
 		bodyReader = bytes.NewReader(body)
 	}
-	req, err := NewRequest(method, url, header, bodyReader)
+	req, err := NewRequestContext(ctx, method, url, header, bodyReader)
 	if err != nil {
 		return nil, err
 	}
 	if client.Credentials != nil {
-		client.SignRequest(req, body)
+		req = withRequestAuth(req, client.signRequest(req, body))
 	}
 	return req, nil
 }
 
-func (client *Client) requestJSON(method string, url urlFunc, reqHeader http.Header, body []byte, data interface{}) (header http.Header, err error) {
-	return header, client.Request(func(server *MetaPostServer) error {
-		header, err = client.requestJSONURL(method, url(server), reqHeader, body, data)
+func (client *Client) requestJSON(ctx context.Context, method string, url urlFunc, reqHeader http.Header, body []byte, data interface{}) (header http.Header, err error) {
+	return header, client.request(ctx, idempotentMethod(method), func(server *MetaPostServer) error {
+		header, err = client.requestJSONURL(ctx, method, url(server), reqHeader, body, data)
 		return err
 	})
 }
 
-func (client *Client) requestJSONURL(method string, url string, header http.Header, body []byte, data interface{}) (http.Header, error) {
-	req, err := client.NewRequest(method, url, header, body)
+func (client *Client) requestJSONURL(ctx context.Context, method string, url string, header http.Header, body []byte, data interface{}) (http.Header, error) {
+	req, err := client.NewRequestContext(ctx, method, url, header, body)
 	if err != nil {
 		return nil, err
 	}
-	res, err := HTTP.Do(req)
+	res, err := client.do(req)
 	if err != nil {
-		return nil, newRequestError(err, req)
+		return nil, err
 	}
 	defer res.Body.Close()
 	if res.StatusCode != 200 {
-		return nil, newResponseError(ErrBadStatusCode, res)
-	}
-	if ok := timeoutRead(res.Body, func() {
-		err = json.NewDecoder(res.Body).Decode(data)
-	}); !ok {
-		return nil, newResponseError(ErrReadTimeout, res)
+		return res.Header, newResponseError(ErrBadStatusCode, res)
 	}
+	err = client.readBody(res, func(body io.Reader) error {
+		return json.NewDecoder(body).Decode(data)
+	})
 	return res.Header, err
 }
 
 type urlFunc func(server *MetaPostServer) string
 
-func (client *Client) requestCount(urlFunc urlFunc, header http.Header) (PageHeader, error) {
+func (client *Client) requestCount(ctx context.Context, urlFunc urlFunc, header http.Header) (PageHeader, error) {
 	h := PageHeader{}
-	err := client.Request(func(server *MetaPostServer) error {
-		req, err := client.NewRequest("HEAD", urlFunc(server), header, nil)
+	err := client.RequestContext(ctx, func(server *MetaPostServer) error {
+		req, err := client.NewRequestContext(ctx, "HEAD", urlFunc(server), header, nil)
 		if err != nil {
 			return err
 		}
-		res, err := HTTP.Do(req)
+		res, err := client.do(req)
 		if err != nil {
-			return newRequestError(err, req)
+			return err
 		}
 		res.Body.Close()
 		if res.StatusCode == 304 {
@@ -334,31 +527,168 @@ func SetTimeout(t time.Duration) {
 }
 
 func newHTTPClient(timeout time.Duration) *http.Client {
-	return &http.Client{
-		Transport: &http.Transport{
-			ResponseHeaderTimeout: timeout,
-			Dial: (&net.Dialer{Timeout: timeout}).Dial,
-		},
-	}
-}
-
-func timeoutRead(body io.Closer, read func()) (ok bool) {
-	done := make(chan struct{})
-	go func() {
-		read()
-		done <- struct{}{}
-	}()
-	select {
-	case <-done:
-		return true
-	case <-time.After(timeout):
-		body.Close()
-		return false
+	return &http.Client{Transport: newTransport(timeout)}
+}
+
+func newTransport(timeout time.Duration) *http.Transport {
+	return &http.Transport{
+		ResponseHeaderTimeout: timeout,
+		DialContext:           (&net.Dialer{Timeout: timeout}).DialContext,
+	}
+}
+
+func (client *Client) httpClient() *http.Client {
+	if client.http != nil {
+		return client.http
 	}
+	return HTTP
+}
+
+func (client *Client) readTimeout() time.Duration {
+	if client.timeout > 0 {
+		return client.timeout
+	}
+	return timeout
+}
+
+// do sends req with a cancelable context so that a stalled response body read
+// can be aborted by readBody. The context is released when the body is closed.
+// If the server rejects the request because of clock skew, it is signed again
+// with the corrected time and resent once. Identical GET requests may share
+// a response if request coalescing is enabled.
+func (client *Client) do(req *http.Request) (*http.Response, error) {
+	if client.userAgent != "" {
+		req.Header.Set("User-Agent", client.userAgent)
+	}
+	if immutable, ok := cacheable(req); ok && req.Method == "GET" && client.cache != nil {
+		return client.doCached(req, immutable)
+	}
+	return client.doShared(req)
+}
+
+func (client *Client) doShared(req *http.Request) (*http.Response, error) {
+	if req.Method == "GET" && client.flights != nil {
+		return client.flights.do(client, req, client.sendAuthenticated)
+	}
+	return client.sendAuthenticated(req)
+}
+
+func (client *Client) sendAuthenticated(req *http.Request) (*http.Response, error) {
+	res, err := client.send(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == 401 {
+		if retry := client.correctClockSkew(req, res); retry != nil {
+			res.Body.Close()
+			return client.send(retry)
+		}
+	}
+	return res, nil
+}
+
+func (client *Client) send(req *http.Request) (*http.Response, error) {
+	hooks := client.getHooks()
+	ctx, cancel := context.WithCancel(req.Context())
+	sent := req.WithContext(ctx)
+	if req.Body != nil && req.Body != http.NoBody {
+		sent.Body = &countingBody{ReadCloser: req.Body, done: func(n int64) { hooks.BytesUploaded(sent, n) }}
+	}
+	if err := client.limiter.wait(ctx, req.URL.Host); err != nil {
+		cancel()
+		return nil, newRequestError(err, req)
+	}
+	hooks.RequestStart(sent)
+	start := time.Now()
+	res, err := client.roundTrip()(sent)
+	hooks.RequestEnd(sent, res, err, time.Since(start))
+	if err != nil {
+		cancel()
+		return nil, newRequestError(err, req)
+	}
+	if res.StatusCode == 429 || res.StatusCode == 503 {
+		if d, ok := parseRetryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+			if max := client.retry().maxRetryAfter(); d > max {
+				d = max
+			}
+			client.limiter.pause(req.URL.Host, d)
+		}
+	}
+	res.Body = &cancelBody{
+		countingBody: countingBody{ReadCloser: res.Body, done: func(n int64) { hooks.BytesDownloaded(sent, n) }},
+		cancel:       cancel,
+	}
+	return res, nil
+}
+
+type cancelBody struct {
+	countingBody
+	cancel   context.CancelFunc
+	timedOut atomic.Bool
+}
+
+func (b *cancelBody) timeout() {
+	b.timedOut.Store(true)
+	b.cancel()
+}
+
+func (b *cancelBody) Close() error {
+	err := b.countingBody.Close()
+	b.cancel()
+	return err
+}
+
+// readBody calls read with the response body, aborting the read with
+// ErrReadTimeout if it takes longer than the timeout.
+func (client *Client) readBody(res *http.Response, read func(io.Reader) error) error {
+	body, ok := res.Body.(*cancelBody)
+	if err := client.verifyResponse(res); err != nil {
+		return err
+	}
+	if ok {
+		t := time.AfterFunc(client.readTimeout(), body.timeout)
+		defer t.Stop()
+	}
+	start := time.Now()
+	err := read(res.Body)
+	if _, verified := res.Body.(*verifiedBody); err == nil && verified {
+		// the payload hash is only checked once the whole body has been read
+		_, err = io.Copy(io.Discard, res.Body)
+	}
+	client.getHooks().Decode(res.Request, time.Since(start), err)
+	if err != nil && ok && body.timedOut.Load() {
+		return newResponseError(ErrReadTimeout, res)
+	}
+	return err
+}
+
+// readAll reads the whole response body, applying the read timeout.
+func (client *Client) readAll(res *http.Response) ([]byte, error) {
+	body, ok := res.Body.(*cancelBody)
+	if v, verified := res.Body.(*verifiedBody); verified {
+		body, ok = v.ReadCloser.(*cancelBody)
+	}
+	if ok {
+		t := time.AfterFunc(client.readTimeout(), body.timeout)
+		defer t.Stop()
+	}
+	data, err := io.ReadAll(res.Body)
+	if err != nil && ok && body.timedOut.Load() {
+		return nil, newResponseError(ErrReadTimeout, res)
+	}
+	var resErr *ResponseError
+	if err != nil && !errors.As(err, &resErr) {
+		return nil, newRequestError(err, res.Request)
+	}
+	return data, err
 }
 
 func NewRequest(method, url string, header http.Header, body io.Reader) (*http.Request, error) {
-	req, err := http.NewRequest(method, url, body)
+	return NewRequestContext(context.Background(), method, url, header, body)
+}
+
+func NewRequestContext(ctx context.Context, method, url string, header http.Header, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
 	}
@@ -392,6 +722,18 @@ func (e *RequestError) Error() string {
 	}
 }
 
+func (e *RequestError) Unwrap() error { return e.Err }
+
+// Sentinel errors that a *ResponseError with Type ErrBadStatusCode matches
+// with errors.Is based on the response status code.
+var (
+	ErrNotFound          = errors.New("tent: not found")
+	ErrUnauthorized      = errors.New("tent: unauthorized")
+	ErrForbidden         = errors.New("tent: forbidden")
+	ErrConflict          = errors.New("tent: conflict")
+	ErrServerUnavailable = errors.New("tent: server unavailable")
+)
+
 type ResponseErrorType int
 
 const (
@@ -399,12 +741,16 @@ const (
 	ErrBadContentType
 	ErrBadData
 	ErrReadTimeout
+	ErrBadServerAuth
 )
 
 type ResponseError struct {
 	Type      ResponseErrorType
 	Response  *http.Response
 	TentError *TentError
+
+	// Err is the underlying cause of the error, if any.
+	Err error
 }
 
 type TentError struct {
@@ -412,6 +758,28 @@ type TentError struct {
 	Fields map[string]string `json:"fields"`
 }
 
+// A FieldError describes a problem with a single field of a request, as
+// reported by the server.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string { return e.Field + ": " + e.Message }
+
+// FieldErrors returns the field errors sorted by field name.
+func (e *TentError) FieldErrors() []*FieldError {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	errs := make([]*FieldError, 0, len(e.Fields))
+	for field, msg := range e.Fields {
+		errs = append(errs, &FieldError{Field: field, Message: msg})
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
 const MediaTypeError = "application/vnd.tent.error.v0+json"
 
 func newResponseError(typ ResponseErrorType, res *http.Response) *ResponseError {
@@ -429,6 +797,38 @@ func newResponseError(typ ResponseErrorType, res *http.Response) *ResponseError
 	return err
 }
 
+func (e *ResponseError) Unwrap() error { return e.Err }
+
+// Is reports whether the response status code corresponds to target, which
+// should be one of ErrNotFound, ErrUnauthorized, ErrForbidden, ErrConflict or
+// ErrServerUnavailable.
+func (e *ResponseError) Is(target error) bool {
+	if e.Type != ErrBadStatusCode || e.Response == nil {
+		return false
+	}
+	switch e.Response.StatusCode {
+	case 404:
+		return target == ErrNotFound
+	case 401:
+		return target == ErrUnauthorized
+	case 403:
+		return target == ErrForbidden
+	case 409:
+		return target == ErrConflict
+	case 502, 503, 504:
+		return target == ErrServerUnavailable
+	}
+	return false
+}
+
+// FieldErrors returns the field errors from the error response body, if any.
+func (e *ResponseError) FieldErrors() []*FieldError {
+	if e.TentError == nil {
+		return nil
+	}
+	return e.TentError.FieldErrors()
+}
+
 func (e *ResponseError) Error() string {
 	switch e.Type {
 	case ErrBadContentType:
@@ -441,6 +841,12 @@ func (e *ResponseError) Error() string {
 		}
 	case ErrReadTimeout:
 		return fmt.Sprintf("tent: timeout reading response body of %s %s", e.Response.Request.Method, e.Response.Request.URL)
+	case ErrBadServerAuth:
+		msg := fmt.Sprintf("tent: invalid Server-Authorization for %s %s", e.Response.Request.Method, e.Response.Request.URL)
+		if e.Err != nil {
+			msg += " - " + e.Err.Error()
+		}
+		return msg
 	default:
 		msg := fmt.Sprintf("tent: unexpected %d performing %s %s", e.Response.StatusCode, e.Response.Request.Method, e.Response.Request.URL)
 		if e.TentError != nil {
@@ -0,0 +1,359 @@
+package tent
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/tent/hawk-go"
+	. "launchpad.net/gocheck"
+)
+
+type ClientSuite struct{}
+
+var _ = Suite(&ClientSuite{})
+
+func testServer(url string) MetaPostServer {
+	return MetaPostServer{URLs: MetaPostServerURLs{
+		Post:    url + "/posts/{entity}/{post}",
+		NewPost: url + "/posts",
+	}}
+}
+
+const testPost = `{"post":{"id":"a","type":"https://tent.io/types/status/v0#"}}`
+
+func testClient(urls []string, opts ...ClientOption) *Client {
+	opts = append([]ClientOption{WithRetryPolicy(RetryPolicy{MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond})}, opts...)
+	client := NewPublicClient(opts...)
+	for _, u := range urls {
+		client.Servers = append(client.Servers, testServer(u))
+	}
+	return client
+}
+
+// signResponse sets the Server-Authorization header of a response with the
+// payload hash of body, or without a payload hash if body is nil.
+func signResponse(c *C, w http.ResponseWriter, r *http.Request, creds *hawk.Credentials, body []byte) {
+	auth, err := hawk.NewAuthFromRequest(r, func(*hawk.Credentials) error { return nil }, nil)
+	c.Assert(err, IsNil)
+	auth.Credentials = *creds
+	auth.RequestURI = r.RequestURI
+	auth.Hash = nil
+	if body != nil {
+		h := auth.PayloadHash(MediaTypePost)
+		h.Write(body)
+		auth.SetHash(h)
+	}
+	w.Header().Set("Server-Authorization", auth.ResponseHeader(""))
+	w.Header().Set("Content-Type", MediaTypePost)
+}
+
+func (s *ClientSuite) TestResponseVerification(c *C) {
+	creds := &hawk.Credentials{ID: "id", Key: "key", Hash: sha256.New}
+	body := []byte(testPost)
+	var tamper bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signResponse(c, w, r, creds, body)
+		if tamper {
+			w.Write(bytes.Replace(body, []byte(`"a"`), []byte(`"b"`), 1))
+			return
+		}
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	client := testClient([]string{ts.URL}, WithResponseVerification())
+	client.Credentials = creds
+
+	post, err := client.GetPost("https://example.com", "a", "", nil)
+	c.Assert(err, IsNil)
+	c.Assert(post.Post.ID, Equals, "a")
+
+	tamper = true
+	_, err = client.GetPost("https://example.com", "a", "", nil)
+	resErr, ok := err.(*ResponseError)
+	c.Assert(ok, Equals, true)
+	c.Assert(resErr.Type, Equals, ErrBadServerAuth)
+}
+
+func (s *ClientSuite) TestResponseVerificationMissingHash(c *C) {
+	creds := &hawk.Credentials{ID: "id", Key: "key", Hash: sha256.New}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the request is signed with a payload hash, the response isn't
+		c.Assert(r.Header.Get("Authorization"), Matches, `.*hash=.*`)
+		signResponse(c, w, r, creds, nil)
+		w.Write([]byte(testPost))
+	}))
+	defer ts.Close()
+
+	client := testClient([]string{ts.URL}, WithResponseVerification())
+	client.Credentials = creds
+
+	err := client.CreatePost(&Post{Type: "https://tent.io/types/status/v0#"})
+	resErr, ok := err.(*ResponseError)
+	c.Assert(ok, Equals, true)
+	c.Assert(resErr.Type, Equals, ErrBadServerAuth)
+	c.Assert(resErr.Err, Equals, errMissingPayloadHash)
+}
+
+func (s *ClientSuite) TestClockSkewCorrection(c *C) {
+	creds := &hawk.Credentials{ID: "id", Key: "key", Hash: sha256.New}
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		auth, err := hawk.ParseRequestHeader(r.Header.Get("Authorization"))
+		c.Assert(err, IsNil)
+		serverTime := time.Now().Add(time.Hour)
+		if d := serverTime.Sub(auth.Timestamp); d > time.Minute || d < -time.Minute {
+			ts := strconv.FormatInt(serverTime.Unix(), 10)
+			mac := hmac.New(sha256.New, []byte(creds.Key))
+			mac.Write([]byte("hawk.1.ts\n" + ts + "\n"))
+			tsm := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+			w.Header().Set("WWW-Authenticate", `Hawk ts="`+ts+`", tsm="`+tsm+`", error="Stale timestamp"`)
+			w.WriteHeader(401)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		c.Assert(string(body), Matches, `.*"type":"https://tent.io/types/status/v0#".*`)
+		w.Write([]byte(testPost))
+	}))
+	defer ts.Close()
+
+	client := testClient([]string{ts.URL})
+	client.Credentials = creds
+	err := client.CreatePost(&Post{Type: "https://tent.io/types/status/v0#"})
+	c.Assert(err, IsNil)
+	c.Assert(requests, Equals, 2)
+	c.Assert(client.ClockOffset() > 59*time.Minute, Equals, true)
+
+	// the offset is used for subsequent requests
+	requests = 0
+	err = client.CreatePost(&Post{Type: "https://tent.io/types/status/v0#"})
+	c.Assert(err, IsNil)
+	c.Assert(requests, Equals, 1)
+}
+
+func (s *ClientSuite) TestMiddleware(c *C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Header.Get("X-Test"), Equals, "outer inner")
+		w.Write([]byte(testPost))
+	}))
+	defer ts.Close()
+
+	var calls []string
+	mw := func(name string) Middleware {
+		return func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				c.Assert(req.Header.Get("Authorization"), Matches, "Hawk .*")
+				req.Header.Set("X-Test", strings.TrimSpace(req.Header.Get("X-Test")+" "+name))
+				res, err := next(req)
+				calls = append(calls, name)
+				return res, err
+			}
+		}
+	}
+	client := testClient([]string{ts.URL}, WithMiddleware(mw("outer"), mw("inner")))
+	client.Credentials = &hawk.Credentials{ID: "id", Key: "key", Hash: sha256.New}
+
+	_, err := client.GetPost("https://example.com", "a", "", nil)
+	c.Assert(err, IsNil)
+	c.Assert(calls, DeepEquals, []string{"inner", "outer"})
+}
+
+func (s *ClientSuite) TestErrorSentinels(c *C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", MediaTypeError)
+		w.WriteHeader(403)
+		w.Write([]byte(`{"error":"invalid post","fields":{"type":"is missing","content":"is invalid"}}`))
+	}))
+	defer ts.Close()
+
+	_, err := testClient([]string{ts.URL}).GetPost("https://example.com", "a", "", nil)
+	c.Assert(errors.Is(err, ErrForbidden), Equals, true)
+	c.Assert(errors.Is(err, ErrNotFound), Equals, false)
+	var resErr *ResponseError
+	c.Assert(errors.As(err, &resErr), Equals, true)
+	c.Assert(resErr.FieldErrors(), DeepEquals, []*FieldError{
+		{Field: "content", Message: "is invalid"},
+		{Field: "type", Message: "is missing"},
+	})
+}
+
+func (s *ClientSuite) TestRequestCoalescing(c *C) {
+	var requests int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		w.Write([]byte(testPost))
+	}))
+	defer ts.Close()
+
+	client := testClient([]string{ts.URL}, WithRequestCoalescing())
+
+	const n = 5
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			post, err := client.GetPost("https://example.com", "a", "", nil)
+			if err == nil && post.Post.ID != "a" {
+				err = errors.New("unexpected post")
+			}
+			errs <- err
+		}()
+	}
+	for client.Stats().CoalescedRequests < n-1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	for i := 0; i < n; i++ {
+		c.Assert(<-errs, IsNil)
+	}
+	c.Assert(atomic.LoadInt32(&requests), Equals, int32(1))
+}
+
+func (s *ClientSuite) TestRequestCoalescingVerification(c *C) {
+	creds := &hawk.Credentials{ID: "id", Key: "key", Hash: sha256.New}
+	body := []byte(testPost)
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		signResponse(c, w, r, creds, body)
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	client := testClient([]string{ts.URL}, WithRequestCoalescing(), WithResponseVerification())
+	client.Credentials = creds
+
+	const n = 8
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			post, err := client.GetPost("https://example.com", "a", "", nil)
+			if err == nil && post.Post.ID != "a" {
+				err = errors.New("unexpected post")
+			}
+			errs <- err
+		}()
+	}
+	for client.Stats().CoalescedRequests < n-1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	for i := 0; i < n; i++ {
+		c.Assert(<-errs, IsNil)
+	}
+}
+
+func (s *ClientSuite) TestCancelMidBody(c *C) {
+	started := make(chan struct{})
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1000")
+		w.Write([]byte(`{"post":{"id":"a",`))
+		w.(http.Flusher).Flush()
+		close(started)
+		<-done
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	start := time.Now()
+	_, err := testClient([]string{ts.URL}).GetPostContext(ctx, "https://example.com", "a", "", nil)
+	c.Assert(time.Since(start) < time.Second, Equals, true)
+	c.Assert(errors.Is(err, context.Canceled), Equals, true)
+	var reqErr *RequestError
+	c.Assert(errors.As(err, &reqErr), Equals, true)
+}
+
+func (s *ClientSuite) TestContextDeadline(c *C) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := testClient([]string{ts.URL}).GetPostContext(ctx, "https://example.com", "a", "", nil)
+	c.Assert(errors.Is(err, context.DeadlineExceeded), Equals, true)
+	var reqErr *RequestError
+	c.Assert(errors.As(err, &reqErr), Equals, true)
+}
+
+func (s *ClientSuite) TestCancelAttachmentUpload(c *C) {
+	started := make(chan struct{})
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body.Read(make([]byte, 1))
+		close(started)
+		<-done
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	data := bytes.NewReader(make([]byte, 4<<20))
+	post := &Post{
+		Type:        "https://tent.io/types/photo/v0#",
+		Attachments: []*PostAttachment{{Category: "photo", Name: "a.png", ContentType: "image/png", Data: lenReader{data}}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	start := time.Now()
+	err := testClient([]string{ts.URL}).CreatePostContext(ctx, post)
+	c.Assert(time.Since(start) < time.Second, Equals, true)
+	c.Assert(errors.Is(err, context.Canceled), Equals, true)
+	var reqErr *RequestError
+	c.Assert(errors.As(err, &reqErr), Equals, true)
+
+	// the multipart writer stops reading the attachment
+	remaining := data.Len()
+	c.Assert(remaining > 0, Equals, true)
+	time.Sleep(50 * time.Millisecond)
+	c.Assert(data.Len(), Equals, remaining)
+}
+
+func (s *ClientSuite) TestVersionVerification(c *C) {
+	post := &Post{ID: "a", Entity: "https://example.com", Type: PostTypeStatus, Content: []byte(`{"text":"hi"}`), Version: &PostVersion{}}
+	post.Version.ID, _, _ = post.CalculateVersion()
+	c.Assert(post.VerifyVersion(), IsNil)
+
+	tampered := *post
+	tampered.Content = []byte(`{"text":"bye"}`)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&PostEnvelope{Post: &tampered})
+	}))
+	defer ts.Close()
+
+	client := testClient([]string{ts.URL})
+	_, err := client.GetPost("https://example.com", "a", "", nil)
+	c.Assert(err, IsNil)
+	_, err = client.GetPost("https://example.com", "a", "", &PostRequest{VerifyVersion: true})
+	c.Assert(errors.Is(err, ErrVersionMismatch), Equals, true)
+
+	client = NewPublicClient(WithVersionVerification())
+	data, _ := json.Marshal(&tampered)
+	_, err = client.ReadNotification(httptest.NewRequest("PUT", "/notify", bytes.NewReader(data)))
+	c.Assert(errors.Is(err, ErrVersionMismatch), Equals, true)
+}
@@ -0,0 +1,43 @@
+package tent
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ClockOffset returns the difference between the server clock and the local
+// clock, as learned from Hawk stale timestamp errors. It is applied to the
+// timestamp of every signed request.
+func (client *Client) ClockOffset() time.Duration {
+	return time.Duration(client.clockOffset.Load())
+}
+
+// correctClockSkew checks if res is a Hawk stale timestamp error with a valid
+// timestamp MAC. If it is, the client clock offset is updated and a copy of
+// req signed with the corrected timestamp is returned. Otherwise nil is
+// returned.
+func (client *Client) correctClockSkew(req *http.Request, res *http.Response) *http.Request {
+	auth := requestAuth(req)
+	header := res.Header.Get("WWW-Authenticate")
+	if auth == nil || !strings.Contains(header, "tsm=") {
+		return nil
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return nil
+	}
+	offset, err := auth.UpdateOffset(header)
+	if err != nil {
+		return nil
+	}
+	client.clockOffset.Store(int64(offset))
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil
+		}
+	}
+	retry.Header.Set("Authorization", auth.RequestHeader())
+	return retry
+}
@@ -0,0 +1,118 @@
+package tent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+var ErrUnknownContentType = errors.New("tent: no content type registered for post type")
+
+var contentTypes = struct {
+	sync.RWMutex
+	byType map[string]reflect.Type
+	byGo   map[reflect.Type]string
+}{byType: make(map[string]reflect.Type), byGo: make(map[reflect.Type]string)}
+
+// RegisterContentType registers the Go type of prototype as the content type of
+// posts with the type typeURI. The fragment of typeURI is ignored, so the
+// registered type is used for every fragment.
+func RegisterContentType(typeURI string, prototype interface{}) {
+	t := reflect.TypeOf(prototype)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	base := TypeBase(typeURI)
+	contentTypes.Lock()
+	contentTypes.byType[base] = t
+	contentTypes.byGo[t] = base
+	contentTypes.Unlock()
+}
+
+func registeredContentType(typ string) (reflect.Type, bool) {
+	contentTypes.RLock()
+	defer contentTypes.RUnlock()
+	t, ok := contentTypes.byType[TypeBase(typ)]
+	return t, ok
+}
+
+// DecodeContent decodes the post content into a new value of the type
+// registered for the post type, and returns a pointer to it.
+func (post *Post) DecodeContent() (interface{}, error) {
+	if post.content != nil && bytes.Equal(post.contentData, post.Content) {
+		return post.content, nil
+	}
+	t, ok := registeredContentType(post.Type)
+	if !ok {
+		return nil, ErrUnknownContentType
+	}
+	v := reflect.New(t).Interface()
+	if len(post.Content) > 0 {
+		if err := json.Unmarshal(post.Content, v); err != nil {
+			return nil, err
+		}
+	}
+	// keep a copy, as Content may be modified in place
+	post.content, post.contentData = v, append([]byte(nil), post.Content...)
+	return v, nil
+}
+
+// SetContent encodes v as the post content. If the post has no type and the
+// type of v is registered, the post type is set to the registered type with an
+// empty fragment.
+func (post *Post) SetContent(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if post.Type == "" {
+		t := reflect.TypeOf(v)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		contentTypes.RLock()
+		base, ok := contentTypes.byGo[t]
+		contentTypes.RUnlock()
+		if ok {
+			post.Type = base + "#"
+		}
+	}
+	post.Content = data
+	post.content, post.contentData = nil, nil
+	return nil
+}
+
+// WithContentDecoding makes the client decode the content of posts with
+// registered types as they are received, so that DecodeContent doesn't need to
+// decode them again. Posts with invalid content are left to DecodeContent to
+// report the error.
+func WithContentDecoding() ClientOption {
+	return func(client *Client) { client.decodeContent = true }
+}
+
+func (client *Client) decodePostContent(post *Post) {
+	if client.decodeContent && post != nil {
+		post.DecodeContent()
+	}
+}
+
+func (client *Client) decodeEnvelopeContent(env *PostEnvelope) {
+	if !client.decodeContent {
+		return
+	}
+	client.decodePostContent(env.Post)
+	for i := range env.Refs {
+		client.decodePostContent(&env.Refs[i])
+	}
+}
+
+func (client *Client) decodePageContent(page *PostListPage) {
+	if !client.decodeContent {
+		return
+	}
+	for _, p := range page.Posts {
+		client.decodePostContent(p)
+	}
+}
@@ -0,0 +1,104 @@
+package tent
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "launchpad.net/gocheck"
+)
+
+type ContentSuite struct{}
+
+var _ = Suite(&ContentSuite{})
+
+type testContent struct {
+	Text string `json:"text"`
+}
+
+const testContentType = "https://example.com/types/test/v0#"
+
+func init() {
+	RegisterContentType(testContentType, testContent{})
+}
+
+func (s *ContentSuite) TestDecodeContent(c *C) {
+	post := &Post{Type: "https://example.com/types/test/v0#reply", Content: []byte(`{"text":"hi"}`)}
+	v, err := post.DecodeContent()
+	c.Assert(err, IsNil)
+	c.Assert(v.(*testContent).Text, Equals, "hi")
+
+	_, err = (&Post{Type: "https://example.com/types/unknown/v0#"}).DecodeContent()
+	c.Assert(err, Equals, ErrUnknownContentType)
+}
+
+func (s *ContentSuite) TestDecodeContentModifiedInPlace(c *C) {
+	post := &Post{Type: testContentType, Content: []byte(`{"text":"hi"}`)}
+	v, err := post.DecodeContent()
+	c.Assert(err, IsNil)
+	c.Assert(v.(*testContent).Text, Equals, "hi")
+
+	copy(post.Content[9:], "yo")
+	v, err = post.DecodeContent()
+	c.Assert(err, IsNil)
+	c.Assert(v.(*testContent).Text, Equals, "yo")
+}
+
+func (s *ContentSuite) TestSetContent(c *C) {
+	post := &Post{}
+	c.Assert(post.SetContent(&testContent{Text: "hi"}), IsNil)
+	c.Assert(post.Type, Equals, testContentType)
+	c.Assert(string(post.Content), Equals, `{"text":"hi"}`)
+}
+
+func (s *ContentSuite) TestEagerDecoding(c *C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"post":{"id":"a","type":"https://example.com/types/test/v0#","content":{"text":"hi"}}}`))
+	}))
+	defer ts.Close()
+
+	client := testClient([]string{ts.URL}, WithContentDecoding())
+	env, err := client.GetPost("https://example.com", "a", "", nil)
+	c.Assert(err, IsNil)
+	c.Assert(env.Post.content, FitsTypeOf, &testContent{})
+	v, err := env.Post.DecodeContent()
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, env.Post.content)
+}
+
+func (s *ContentSuite) TestBuiltinTypes(c *C) {
+	post := NewStatusPost(&Status{Text: "hello"}, nil)
+	c.Assert(string(post.Content), Equals, `{"text":"hello"}`)
+	c.Assert(post.Type, Equals, PostTypeStatus)
+	c.Assert(post.Permissions.Public(), Equals, true)
+	status, err := post.Status()
+	c.Assert(err, IsNil)
+	c.Assert(status.Text, Equals, "hello")
+	_, err = post.Essay()
+	c.Assert(err, Equals, ErrWrongPostType)
+
+	post.ID, post.Entity = "a", "https://example.com"
+	repost := NewRepostPost(&Repost{}, post)
+	c.Assert(repost.Type, Equals, PostTypeRepost+"https://tent.io/types/status/v0")
+	c.Assert(repost.Refs[0].Post, Equals, "a")
+	_, err = repost.Repost()
+	c.Assert(err, IsNil)
+
+	reply := NewStatusPost(&Status{Text: "hi"}, &PostPermissions{PublicFlag: new(bool), Entities: []string{post.Entity}},
+		PostMention{Entity: post.Entity, Post: post.ID})
+	c.Assert(reply.Type, Equals, PostTypeStatusReply)
+	c.Assert(reply.Permissions.Public(), Equals, false)
+	c.Assert(reply.Mentions, HasLen, 1)
+	status, err = reply.Status()
+	c.Assert(err, IsNil)
+	c.Assert(status.Text, Equals, "hi")
+
+	photo := NewPhotoPost(&Photo{}, nil, nil)
+	c.Assert(photo.Attachments, HasLen, 0)
+	photo = NewPhotoPost(&Photo{}, &PostAttachment{Name: "a.png"}, nil, PostMention{Entity: post.Entity})
+	c.Assert(photo.Attachments, HasLen, 1)
+	c.Assert(photo.Mentions, HasLen, 1)
+
+	album := NewAlbumPost(&Album{}, []*Post{post}, reply.Permissions)
+	c.Assert(album.Refs, HasLen, 1)
+	c.Assert(album.Permissions.Public(), Equals, false)
+}
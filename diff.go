@@ -0,0 +1,314 @@
+package tent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cupcake/sfilter"
+	"github.com/tent/canonical-json-go"
+)
+
+type ChangeKind int
+
+const (
+	ChangeAdded ChangeKind = iota
+	ChangeRemoved
+	ChangeModified
+)
+
+var changeKindName = [...]string{"added", "removed", "modified"}
+
+func (k ChangeKind) String() string {
+	if k < 0 || int(k) >= len(changeKindName) {
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+	return changeKindName[k]
+}
+
+// A Change is a difference between two versions of a post. Path is a JSON
+// pointer into the post, for example "/content/title" or "/mentions/0". Old
+// and New hold the JSON values, and are nil if the value was added or removed.
+type Change struct {
+	Kind ChangeKind
+	Path string
+	Old  json.RawMessage
+	New  json.RawMessage
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("%s added: %s", c.Path, c.New)
+	case ChangeRemoved:
+		return fmt.Sprintf("%s removed: %s", c.Path, c.Old)
+	default:
+		return fmt.Sprintf("%s changed: %s -> %s", c.Path, c.Old, c.New)
+	}
+}
+
+// setFields are the post fields that are compared as sets, so that reordering
+// their elements is not reported as a change.
+var setFields = map[string]bool{
+	"/mentions":             true,
+	"/refs":                 true,
+	"/licenses":             true,
+	"/attachments":          true,
+	"/permissions/groups":   true,
+	"/permissions/entities": true,
+}
+
+// DiffPosts returns the changes from a to b in the fields that make up the
+// post version, and the permissions. The version metadata itself is ignored.
+func DiffPosts(a, b *Post) []Change {
+	var changes []Change
+	diffValues(&changes, "", diffMap(a), diffMap(b), true, true)
+	return changes
+}
+
+// diffMap returns the fields of post that are compared by DiffPosts as
+// decoded JSON.
+func diffMap(post *Post) map[string]interface{} {
+	data, _ := sfilter.Map(post, "version")
+	delete(data, "version")
+	if post.Permissions != nil {
+		data["permissions"] = post.Permissions
+	}
+	res, _ := toJSONValue(data).v.(map[string]interface{})
+	if res == nil {
+		res = make(map[string]interface{})
+	}
+	return res
+}
+
+func diffValues(changes *[]Change, path string, a, b interface{}, hasA, hasB bool) {
+	switch {
+	case !hasA && !hasB:
+		return
+	case !hasA:
+		*changes = append(*changes, Change{Kind: ChangeAdded, Path: path, New: jsonValue{b, true}.raw()})
+		return
+	case !hasB:
+		*changes = append(*changes, Change{Kind: ChangeRemoved, Path: path, Old: jsonValue{a, true}.raw()})
+		return
+	case (jsonValue{a, true}).equal(jsonValue{b, true}):
+		return
+	}
+
+	ao, aok := a.(map[string]interface{})
+	bo, bok := b.(map[string]interface{})
+	if aok && bok {
+		keys := make([]string, 0, len(ao)+len(bo))
+		for k := range ao {
+			keys = append(keys, k)
+		}
+		for k := range bo {
+			if _, ok := ao[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			av, ha := ao[k]
+			bv, hb := bo[k]
+			diffValues(changes, path+"/"+escapePointer(k), av, bv, ha, hb)
+		}
+		return
+	}
+
+	al, alok := a.([]interface{})
+	bl, blok := b.([]interface{})
+	if alok && blok && setFields[path] {
+		diffSets(changes, path, al, bl)
+		return
+	}
+	if alok && blok {
+		for i := 0; i < len(al) || i < len(bl); i++ {
+			var av, bv interface{}
+			if i < len(al) {
+				av = al[i]
+			}
+			if i < len(bl) {
+				bv = bl[i]
+			}
+			diffValues(changes, path+"/"+strconv.Itoa(i), av, bv, i < len(al), i < len(bl))
+		}
+		return
+	}
+
+	*changes = append(*changes, Change{Kind: ChangeModified, Path: path, Old: jsonValue{a, true}.raw(), New: jsonValue{b, true}.raw()})
+}
+
+// diffSets reports the elements removed from a and added in b. Attachments
+// with the same name and category are reported as modified, so that a changed
+// digest is a single change.
+func diffSets(changes *[]Change, path string, a, b []interface{}) {
+	key := func(v interface{}) string {
+		data, _ := cjson.Marshal(v)
+		return string(data)
+	}
+	inA := make(map[string]bool, len(a))
+	for _, v := range a {
+		inA[key(v)] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, v := range b {
+		inB[key(v)] = true
+	}
+
+	added := make(map[string]int)
+	if path == "/attachments" {
+		for i, v := range b {
+			if !inA[key(v)] {
+				added[attachmentKey(v)] = i
+			}
+		}
+	}
+	modified := make(map[int]bool)
+	for i, v := range a {
+		if inB[key(v)] {
+			continue
+		}
+		if j, ok := added[attachmentKey(v)]; ok && path == "/attachments" && !modified[j] {
+			modified[j] = true
+			diffValues(changes, path+"/"+strconv.Itoa(j), v, b[j], true, true)
+			continue
+		}
+		*changes = append(*changes, Change{Kind: ChangeRemoved, Path: path + "/" + strconv.Itoa(i), Old: jsonValue{v, true}.raw()})
+	}
+	for i, v := range b {
+		if !inA[key(v)] && !modified[i] {
+			*changes = append(*changes, Change{Kind: ChangeAdded, Path: path + "/" + strconv.Itoa(i), New: jsonValue{v, true}.raw()})
+		}
+	}
+}
+
+func attachmentKey(v interface{}) string {
+	m, _ := v.(map[string]interface{})
+	name, _ := m["name"].(string)
+	category, _ := m["category"].(string)
+	return category + "/" + name
+}
+
+// UnifiedDiff renders the difference between a and b as a unified diff of
+// their indented JSON, limited to the fields compared by DiffPosts.
+func UnifiedDiff(a, b *Post) string {
+	return unifiedDiff(postLabel(a), postLabel(b), diffLines(a), diffLines(b))
+}
+
+func unifiedDiff(labelA, labelB string, linesA, linesB []string) string {
+	ops := lineDiff(linesA, linesB)
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "--- %s\n+++ %s\n", labelA, labelB)
+	const context = 3
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// extend the hunk while changes are within 2*context lines of
+		// each other
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(ops) && j-end <= 2*context; j++ {
+			if ops[j].kind != ' ' {
+				end = j
+			}
+		}
+		end += context + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		startA, startB := ops[start].a, ops[start].b
+		var countA, countB int
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				countA++
+			}
+			if op.kind != '-' {
+				countB++
+			}
+		}
+		fmt.Fprintf(buf, "@@ -%s +%s @@\n", hunkRange(startA, countA), hunkRange(startB, countB))
+		for _, op := range ops[start:end] {
+			buf.WriteByte(op.kind)
+			buf.WriteString(op.line)
+			buf.WriteByte('\n')
+		}
+		i = end
+	}
+	return buf.String()
+}
+
+// hunkRange formats the start line and line count of a hunk. An empty range
+// starts at the line before the hunk, as in GNU diff.
+func hunkRange(start, count int) string {
+	if count > 0 {
+		start++
+	}
+	return strconv.Itoa(start) + "," + strconv.Itoa(count)
+}
+
+func postLabel(post *Post) string {
+	if post.Version != nil && post.Version.ID != "" {
+		return post.ID + "@" + post.Version.ID
+	}
+	return post.ID
+}
+
+func diffLines(post *Post) []string {
+	data, _ := json.MarshalIndent(diffMap(post), "", "  ")
+	return strings.Split(string(data), "\n")
+}
+
+type lineOp struct {
+	kind byte
+	line string
+	// line indexes in a and b at this point
+	a, b int
+}
+
+// lineDiff returns the edit script from a to b using the longest common
+// subsequence of lines.
+func lineDiff(a, b []string) []lineOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var ops []lineOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, lineOp{' ', a[i], i, j})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, lineOp{'+', b[j], i, j})
+			j++
+		default:
+			ops = append(ops, lineOp{'-', a[i], i, j})
+			i++
+		}
+	}
+	return ops
+}
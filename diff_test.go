@@ -0,0 +1,80 @@
+package tent
+
+import . "launchpad.net/gocheck"
+
+type DiffSuite struct{}
+
+var _ = Suite(&DiffSuite{})
+
+func (s *DiffSuite) TestDiffPosts(c *C) {
+	a := &Post{ID: "p", Type: PostTypeEssay, Version: &PostVersion{ID: "v1"},
+		Content:     []byte(`{"title":"t","body":"x"}`),
+		Mentions:    []PostMention{{Entity: "https://one.example.com"}},
+		Attachments: []*PostAttachment{{Name: "a.png", Category: "photo", ContentType: "image/png", Digest: "d1"}},
+	}
+	b := &Post{ID: "p", Type: PostTypeEssay, Version: &PostVersion{ID: "v2"},
+		Content:     []byte(`{"title":"T","body":"x","tags":["a/b"]}`),
+		Mentions:    []PostMention{{Entity: "https://two.example.com"}},
+		Attachments: []*PostAttachment{{Name: "a.png", Category: "photo", ContentType: "image/png", Digest: "d2"}},
+		Permissions: &PostPermissions{PublicFlag: new(bool)},
+	}
+	changes := DiffPosts(a, b)
+	paths := make([]string, len(changes))
+	for i, ch := range changes {
+		paths[i] = ch.Kind.String() + " " + ch.Path
+	}
+	c.Assert(paths, DeepEquals, []string{
+		"modified /attachments/0/digest",
+		"added /content/tags",
+		"modified /content/title",
+		"removed /mentions/0",
+		"added /mentions/0",
+		"added /permissions",
+	})
+	c.Assert(string(changes[0].Old), Equals, `"d1"`)
+
+	diff := UnifiedDiff(a, b)
+	c.Assert(diff, Matches, `(?s)--- p@v1\n\+\+\+ p@v2\n@@ .*-.*"title": "t".*\+.*"title": "T".*`)
+}
+
+func (s *DiffSuite) TestChangeKindString(c *C) {
+	c.Assert(ChangeRemoved.String(), Equals, "removed")
+	c.Assert(ChangeKind(7).String(), Equals, "ChangeKind(7)")
+	c.Assert(ChangeKind(-1).String(), Equals, "ChangeKind(-1)")
+}
+
+func (s *DiffSuite) TestUnifiedDiff(c *C) {
+	a := &Post{ID: "p", Type: PostTypeEssay, Version: &PostVersion{ID: "v1"},
+		Content: []byte(`{"a":1,"b":2,"c":3,"d":4,"e":5,"f":6,"g":7,"h":8,"i":9,"j":10}`),
+	}
+	c.Assert(UnifiedDiff(a, a), Equals, "--- p@v1\n+++ p@v1\n")
+
+	b := &Post{ID: "p", Type: PostTypeEssay, Version: &PostVersion{ID: "v2"},
+		Content: []byte(`{"a":0,"b":2,"c":3,"d":4,"e":5,"f":6,"g":7,"h":8,"i":9,"j":11}`),
+	}
+	c.Assert(UnifiedDiff(a, b), Equals, `--- p@v1
++++ p@v2
+@@ -1,6 +1,6 @@
+ {
+   "content": {
+-    "a": 1,
++    "a": 0,
+     "b": 2,
+     "c": 3,
+     "d": 4,
+@@ -9,7 +9,7 @@
+     "g": 7,
+     "h": 8,
+     "i": 9,
+-    "j": 10
++    "j": 11
+   },
+   "id": "p",
+   "type": "https://tent.io/types/essay/v0#"
+`)
+}
+
+func (s *DiffSuite) TestUnifiedDiffEmptyRange(c *C) {
+	c.Assert(unifiedDiff("a", "b", nil, []string{"x", "y"}), Equals, "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n")
+	c.Assert(unifiedDiff("a", "b", []string{"x"}, nil), Equals, "--- a\n+++ b\n@@ -1,1 +0,0 @@\n-x\n")
+}
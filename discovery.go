@@ -2,6 +2,7 @@ package tent
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"mime"
@@ -13,14 +14,31 @@ import (
 var ErrNotTentEntity = errors.New("tent: not a valid Tent entity")
 var ErrInvalidLink = errors.New("tent: invalid meta Link")
 
+// Discover is equivalent to (&Client{}).Discover.
 func Discover(entity string) (*MetaPost, error) {
-	req, err := NewRequest("HEAD", entity, nil, nil)
+	return DiscoverContext(context.Background(), entity)
+}
+
+func DiscoverContext(ctx context.Context, entity string) (*MetaPost, error) {
+	return (&Client{}).DiscoverContext(ctx, entity)
+}
+
+func (client *Client) Discover(entity string) (*MetaPost, error) {
+	return client.DiscoverContext(context.Background(), entity)
+}
+
+func (client *Client) DiscoverContext(ctx context.Context, entity string) (*MetaPost, error) {
+	ctx = withEndpoint(ctx, EndpointDiscovery)
+	req, err := NewRequestContext(ctx, "HEAD", entity, nil, nil)
+	if err != nil {
+		return nil, err
+	}
 	if req.URL.Path == "" {
 		req.URL.Path = "/"
 	}
-	res, err := HTTP.Do(req)
+	res, err := client.do(req)
 	if err != nil {
-		return nil, newRequestError(err, req)
+		return nil, err
 	}
 	res.Body.Close()
 	if res.StatusCode < 200 || res.StatusCode >= 300 {
@@ -39,16 +57,16 @@ func Discover(entity string) (*MetaPost, error) {
 			}
 		}
 		if len(metaLinks) > 0 {
-			return getMetaPost(metaLinks, res.Request.URL)
+			return client.getMetaPost(ctx, metaLinks, res.Request.URL)
 		}
 	}
 
 	// we didn't get anything with the HEAD request, so let's try to GET HTML links
-	req, _ = NewRequest("GET", entity, nil, nil)
+	req, _ = NewRequestContext(ctx, "GET", entity, nil, nil)
 	req.Header.Set("Accept", "text/html")
-	res, err = HTTP.Do(req)
+	res, err = client.do(req)
 	if err != nil {
-		return nil, newRequestError(err, req)
+		return nil, err
 	}
 	defer res.Body.Close()
 	if res.StatusCode != 200 {
@@ -67,16 +85,15 @@ func Discover(entity string) (*MetaPost, error) {
 	}
 
 	var links []string
-	if ok := timeoutRead(res.Body, func() {
-		links, err = parseHTMLMetaLinks(res.Body)
-	}); !ok {
-		return nil, newResponseError(ErrReadTimeout, res)
-	}
+	err = client.readBody(res, func(body io.Reader) (err error) {
+		links, err = parseHTMLMetaLinks(body)
+		return
+	})
 	if err != nil {
 		return nil, err
 	}
 	if len(links) > 0 {
-		return getMetaPost(links, res.Request.URL)
+		return client.getMetaPost(ctx, links, res.Request.URL)
 	}
 
 	return nil, ErrNotTentEntity
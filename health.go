@@ -0,0 +1,81 @@
+package tent
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultServerCooldown is how long a server that failed with a transient
+// error is tried after the other servers.
+var DefaultServerCooldown = 30 * time.Second
+
+// WithServerCooldown sets how long a server that failed with a transient error
+// is tried after the other servers.
+func WithServerCooldown(d time.Duration) ClientOption {
+	return func(client *Client) { client.health = newServerHealth(d) }
+}
+
+// serverHealth tracks servers that have recently failed so that requests can
+// be routed around them until their cool-down expires.
+type serverHealth struct {
+	cooldown time.Duration
+
+	mtx  sync.Mutex
+	down map[MetaPostServerURLs]time.Time
+}
+
+func newServerHealth(cooldown time.Duration) *serverHealth {
+	return &serverHealth{cooldown: cooldown, down: make(map[MetaPostServerURLs]time.Time)}
+}
+
+func (h *serverHealth) failure(server *MetaPostServer) {
+	if h == nil {
+		return
+	}
+	h.mtx.Lock()
+	h.down[server.URLs] = time.Now().Add(h.cooldown)
+	h.mtx.Unlock()
+}
+
+func (h *serverHealth) success(server *MetaPostServer) {
+	if h == nil {
+		return
+	}
+	h.mtx.Lock()
+	delete(h.down, server.URLs)
+	h.mtx.Unlock()
+}
+
+func (h *serverHealth) healthy(server *MetaPostServer, now time.Time) bool {
+	if h == nil {
+		return true
+	}
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	until, ok := h.down[server.URLs]
+	if ok && now.After(until) {
+		delete(h.down, server.URLs)
+		return true
+	}
+	return !ok
+}
+
+// orderedServers returns the client's servers sorted by preference, lowest
+// first, with servers in their cool-down period moved to the end.
+func (client *Client) orderedServers() []MetaPostServer {
+	now := time.Now()
+	servers := make([]MetaPostServer, len(client.Servers))
+	copy(servers, client.Servers)
+	down := make(map[MetaPostServerURLs]bool, len(servers))
+	for i := range servers {
+		down[servers[i].URLs] = !client.health.healthy(&servers[i], now)
+	}
+	sort.SliceStable(servers, func(i, j int) bool {
+		if di, dj := down[servers[i].URLs], down[servers[j].URLs]; di != dj {
+			return dj
+		}
+		return servers[i].Preference < servers[j].Preference
+	})
+	return servers
+}
@@ -0,0 +1,107 @@
+package tent
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Endpoint names used to label requests. They match the JSON names of the
+// MetaPostServerURLs fields, except EndpointDiscovery.
+const (
+	EndpointOAuthToken     = "oauth_token"
+	EndpointPostsFeed      = "posts_feed"
+	EndpointPost           = "post"
+	EndpointNewPost        = "new_post"
+	EndpointPostAttachment = "post_attachment"
+	EndpointAttachment     = "attachment"
+	EndpointBatch          = "batch"
+	EndpointServerInfo     = "server_info"
+	EndpointDiscovery      = "discovery"
+)
+
+type endpointKey struct{}
+
+func withEndpoint(ctx context.Context, endpoint string) context.Context {
+	return context.WithValue(ctx, endpointKey{}, endpoint)
+}
+
+// RequestEndpoint returns the name of the endpoint that a request made by
+// a Client was sent to, or an empty string if it is unknown.
+func RequestEndpoint(req *http.Request) string {
+	endpoint, _ := req.Context().Value(endpointKey{}).(string)
+	return endpoint
+}
+
+// Hooks receives callbacks about the requests made by a Client. The methods
+// may be called concurrently and must not block.
+type Hooks interface {
+	// RequestStart is called before each HTTP request is sent.
+	RequestStart(req *http.Request)
+	// RequestEnd is called when the response headers have been received or
+	// the request failed. res is nil if err is not nil.
+	RequestEnd(req *http.Request, res *http.Response, err error, d time.Duration)
+	// ServerFailover is called when a request moves to a different server
+	// after err.
+	ServerFailover(from, to *MetaPostServer, err error)
+	// Retry is called when every server has failed and the request is about
+	// to be retried after a backoff delay. attempt counts the retry rounds
+	// from one and wait is the delay before the round starts.
+	Retry(attempt int, err error, wait time.Duration)
+	// BytesUploaded is called with the size of the request body once it has
+	// been sent.
+	BytesUploaded(req *http.Request, n int64)
+	// BytesDownloaded is called with the number of response body bytes read
+	// when the body is closed.
+	BytesDownloaded(req *http.Request, n int64)
+	// Decode is called after a response body has been decoded.
+	Decode(req *http.Request, d time.Duration, err error)
+}
+
+// NopHooks implements Hooks with methods that do nothing. It can be embedded
+// to implement a subset of Hooks.
+type NopHooks struct{}
+
+func (NopHooks) RequestStart(*http.Request)                                     {}
+func (NopHooks) RequestEnd(*http.Request, *http.Response, error, time.Duration) {}
+func (NopHooks) ServerFailover(from, to *MetaPostServer, err error)             {}
+func (NopHooks) Retry(int, error, time.Duration)                                {}
+func (NopHooks) BytesUploaded(*http.Request, int64)                             {}
+func (NopHooks) BytesDownloaded(*http.Request, int64)                           {}
+func (NopHooks) Decode(*http.Request, time.Duration, error)                     {}
+
+// WithHooks sets the hooks that receive callbacks about requests.
+func WithHooks(h Hooks) ClientOption {
+	return func(client *Client) { client.hooks = h }
+}
+
+func (client *Client) getHooks() Hooks {
+	if client.hooks != nil {
+		return client.hooks
+	}
+	return NopHooks{}
+}
+
+// countingBody reports the number of bytes read from a request or response
+// body when it is closed.
+type countingBody struct {
+	io.ReadCloser
+	n    int64
+	done func(int64)
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	err := b.ReadCloser.Close()
+	if b.done != nil {
+		b.done(b.n)
+		b.done = nil
+	}
+	return err
+}
@@ -0,0 +1,133 @@
+package tent
+
+import (
+	"expvar"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds of the request latency histogram
+// buckets kept by ExpvarHooks.
+var LatencyBuckets = []time.Duration{
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// ExpvarHooks implements Hooks by publishing metrics with the expvar package.
+// Request metrics are kept per endpoint:
+//
+//	{
+//	  "failovers": 1,
+//	  "retries": 2,
+//	  "post": {
+//	    "requests": 10,
+//	    "errors": 1,
+//	    "status": {"200": 8, "404": 1},
+//	    "latency_ms": {"10": 2, "25": 5, ..., "+Inf": 0},
+//	    "bytes_uploaded": 0,
+//	    "bytes_downloaded": 4096,
+//	    "decodes": 8,
+//	    "decode_errors": 0,
+//	    "decode_ns": 1200000
+//	  }
+//	}
+//
+// Latency buckets are not cumulative, each request is counted in the first
+// bucket with an upper bound greater than or equal to its latency.
+type ExpvarHooks struct {
+	vars *expvar.Map
+
+	mtx       sync.Mutex
+	endpoints map[string]*expvar.Map
+}
+
+// NewExpvarHooks publishes a map of metrics with the given name. Like
+// expvar.Publish, it panics if the name is already in use.
+func NewExpvarHooks(name string) *ExpvarHooks {
+	return &ExpvarHooks{vars: expvar.NewMap(name), endpoints: make(map[string]*expvar.Map)}
+}
+
+func (h *ExpvarHooks) endpoint(req *http.Request) *expvar.Map {
+	name := RequestEndpoint(req)
+	if name == "" {
+		name = "unknown"
+	}
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	m, ok := h.endpoints[name]
+	if !ok {
+		m = new(expvar.Map).Init()
+		m.Set("status", new(expvar.Map).Init())
+		latency := new(expvar.Map).Init()
+		for _, b := range LatencyBuckets {
+			latency.Add(latencyBucketName(b), 0)
+		}
+		latency.Add("+Inf", 0)
+		m.Set("latency_ms", latency)
+		h.endpoints[name] = m
+		h.vars.Set(name, m)
+	}
+	return m
+}
+
+func latencyBucketName(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds()*1000, 'f', -1, 64)
+}
+
+func latencyBucket(d time.Duration) string {
+	for _, b := range LatencyBuckets {
+		if d <= b {
+			return latencyBucketName(b)
+		}
+	}
+	return "+Inf"
+}
+
+func (h *ExpvarHooks) RequestStart(req *http.Request) {
+	h.endpoint(req).Add("requests", 1)
+}
+
+func (h *ExpvarHooks) RequestEnd(req *http.Request, res *http.Response, err error, d time.Duration) {
+	m := h.endpoint(req)
+	m.Get("latency_ms").(*expvar.Map).Add(latencyBucket(d), 1)
+	if err != nil {
+		m.Add("errors", 1)
+		return
+	}
+	m.Get("status").(*expvar.Map).Add(strconv.Itoa(res.StatusCode), 1)
+}
+
+func (h *ExpvarHooks) ServerFailover(from, to *MetaPostServer, err error) {
+	h.vars.Add("failovers", 1)
+}
+
+func (h *ExpvarHooks) Retry(attempt int, err error, wait time.Duration) {
+	h.vars.Add("retries", 1)
+}
+
+func (h *ExpvarHooks) BytesUploaded(req *http.Request, n int64) {
+	h.endpoint(req).Add("bytes_uploaded", n)
+}
+
+func (h *ExpvarHooks) BytesDownloaded(req *http.Request, n int64) {
+	h.endpoint(req).Add("bytes_downloaded", n)
+}
+
+func (h *ExpvarHooks) Decode(req *http.Request, d time.Duration, err error) {
+	m := h.endpoint(req)
+	m.Add("decodes", 1)
+	m.Add("decode_ns", int64(d))
+	if err != nil {
+		m.Add("decode_errors", 1)
+	}
+}
@@ -0,0 +1,160 @@
+package tent
+
+import (
+	"expvar"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	. "launchpad.net/gocheck"
+)
+
+type HooksSuite struct{}
+
+var _ = Suite(&HooksSuite{})
+
+type recordingHooks struct {
+	mtx        sync.Mutex
+	starts     []string
+	status     []int
+	retries    []int
+	failovers  int
+	uploaded   int64
+	downloaded int64
+	decodes    int
+}
+
+func (h *recordingHooks) RequestStart(req *http.Request) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.starts = append(h.starts, RequestEndpoint(req))
+}
+
+func (h *recordingHooks) RequestEnd(req *http.Request, res *http.Response, err error, d time.Duration) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if res != nil {
+		h.status = append(h.status, res.StatusCode)
+	}
+}
+
+func (h *recordingHooks) ServerFailover(from, to *MetaPostServer, err error) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.failovers++
+}
+
+func (h *recordingHooks) Retry(attempt int, err error, wait time.Duration) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.retries = append(h.retries, attempt)
+}
+
+func (h *recordingHooks) BytesUploaded(req *http.Request, n int64) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.uploaded += n
+}
+
+func (h *recordingHooks) BytesDownloaded(req *http.Request, n int64) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.downloaded += n
+}
+
+func (h *recordingHooks) Decode(req *http.Request, d time.Duration, err error) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.decodes++
+}
+
+// failoverServers returns two servers that fail the first three requests made
+// to either of them.
+func failoverServers() (*httptest.Server, *httptest.Server) {
+	var mtx sync.Mutex
+	var requests int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		requests++
+		n := requests
+		mtx.Unlock()
+		if n <= 3 {
+			w.WriteHeader(503)
+			return
+		}
+		w.Write([]byte(testPost))
+	})
+	return httptest.NewServer(handler), httptest.NewServer(handler)
+}
+
+func (s *HooksSuite) TestFailoverAndRetry(c *C) {
+	a, b := failoverServers()
+	defer a.Close()
+	defer b.Close()
+
+	h := &recordingHooks{}
+	client := testClient([]string{a.URL, b.URL}, WithHooks(h), WithRetryPolicy(RetryPolicy{MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond, MaxAttempts: 4}))
+	_, err := client.GetPost("https://example.com", "a", "", nil)
+	c.Assert(err, IsNil)
+
+	c.Assert(h.starts, DeepEquals, []string{EndpointPost, EndpointPost, EndpointPost, EndpointPost})
+	c.Assert(h.status, DeepEquals, []int{503, 503, 503, 200})
+	// the servers are tried in turn, only the second round is a retry
+	c.Assert(h.failovers, Equals, 3)
+	c.Assert(h.retries, DeepEquals, []int{1})
+	c.Assert(h.downloaded, Equals, int64(len(testPost)))
+	c.Assert(h.decodes, Equals, 1)
+}
+
+func (s *HooksSuite) TestBytesUploaded(c *C) {
+	var received int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received = len(body)
+		w.Header().Set("Content-Type", MediaTypePost)
+		w.Write([]byte(testPost))
+	}))
+	defer ts.Close()
+
+	h := &recordingHooks{}
+	err := testClient([]string{ts.URL}, WithHooks(h)).CreatePost(&Post{Type: "https://tent.io/types/status/v0#"})
+	c.Assert(err, IsNil)
+	c.Assert(h.starts, DeepEquals, []string{EndpointNewPost})
+	c.Assert(received > 0, Equals, true)
+	c.Assert(h.uploaded, Equals, int64(received))
+	c.Assert(h.retries, HasLen, 0)
+	c.Assert(h.failovers, Equals, 0)
+}
+
+func (s *HooksSuite) TestExpvarHooks(c *C) {
+	a, b := failoverServers()
+	defer a.Close()
+	defer b.Close()
+
+	h := NewExpvarHooks("tent_hooks_test")
+	client := testClient([]string{a.URL, b.URL}, WithHooks(h), WithRetryPolicy(RetryPolicy{MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond, MaxAttempts: 4}))
+	_, err := client.GetPost("https://example.com", "a", "", nil)
+	c.Assert(err, IsNil)
+
+	c.Assert(h.vars.Get("failovers").String(), Equals, "3")
+	c.Assert(h.vars.Get("retries").String(), Equals, "1")
+	post := h.vars.Get(EndpointPost).(*expvar.Map)
+	c.Assert(post.Get("requests").String(), Equals, "4")
+	c.Assert(post.Get("errors"), IsNil)
+	status := post.Get("status").(*expvar.Map)
+	c.Assert(status.Get("503").String(), Equals, "3")
+	c.Assert(status.Get("200").String(), Equals, "1")
+	c.Assert(post.Get("bytes_downloaded").String(), Equals, strconv.Itoa(len(testPost)))
+	c.Assert(post.Get("decodes").String(), Equals, "1")
+	c.Assert(post.Get("decode_errors"), IsNil)
+
+	var latency int
+	post.Get("latency_ms").(*expvar.Map).Do(func(kv expvar.KeyValue) {
+		n, _ := kv.Value.(*expvar.Int)
+		latency += int(n.Value())
+	})
+	c.Assert(latency, Equals, 4)
+}
@@ -0,0 +1,291 @@
+package tent
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/tent/canonical-json-go"
+)
+
+// A MergeConflict is a value that was changed differently by both versions
+// being merged. Path is a JSON pointer to the value, for example
+// "/content/title" or "/permissions/public". Base, A and B hold the JSON
+// values, and are nil if the value is absent from that version.
+type MergeConflict struct {
+	Path string
+	Base json.RawMessage
+	A    json.RawMessage
+	B    json.RawMessage
+}
+
+// A MergeResolver is called for each conflict and returns the value to use,
+// or nil to leave the value out. Returning invalid JSON makes the merge fail.
+type MergeResolver func(c *MergeConflict) (json.RawMessage, error)
+
+// MergeVersions does a three-way merge of a and b, two versions of a post
+// created from base. Content objects are merged by key, and mentions, refs,
+// attachments and the permission groups and entities are merged as sets.
+// Conflicting values are taken from a and returned as a list.
+//
+// The merged post has both a and b as version parents, so that creating it
+// resolves the divergence. Fields other than the merged ones are copied from a.
+func MergeVersions(base, a, b *Post) (*Post, []*MergeConflict, error) {
+	m := &merger{}
+	post, err := m.mergePosts(base, a, b)
+	return post, m.conflicts, err
+}
+
+// MergeVersionsFunc is like MergeVersions, but calls resolve to choose the
+// value for each conflict.
+func MergeVersionsFunc(base, a, b *Post, resolve MergeResolver) (*Post, error) {
+	m := &merger{resolve: resolve}
+	return m.mergePosts(base, a, b)
+}
+
+type merger struct {
+	resolve   MergeResolver
+	conflicts []*MergeConflict
+}
+
+// jsonValue is a decoded JSON value that may be absent.
+type jsonValue struct {
+	v  interface{}
+	ok bool
+}
+
+func toJSONValue(v interface{}) jsonValue {
+	data, err := json.Marshal(v)
+	if err != nil || string(data) == "null" {
+		return jsonValue{}
+	}
+	var res interface{}
+	if json.Unmarshal(data, &res) != nil {
+		return jsonValue{}
+	}
+	return jsonValue{res, true}
+}
+
+func contentValue(content json.RawMessage) jsonValue {
+	var v interface{}
+	if len(content) == 0 || json.Unmarshal(content, &v) != nil {
+		return jsonValue{}
+	}
+	return jsonValue{v, true}
+}
+
+func (v jsonValue) raw() json.RawMessage {
+	if !v.ok {
+		return nil
+	}
+	data, _ := json.Marshal(v.v)
+	return data
+}
+
+func (v jsonValue) equal(o jsonValue) bool {
+	return v.ok == o.ok && (!v.ok || reflect.DeepEqual(v.v, o.v))
+}
+
+func (m *merger) mergePosts(base, a, b *Post) (*Post, error) {
+	if base == nil {
+		base = &Post{}
+	}
+	res := *a
+	res.Mentions, res.Refs, res.Attachments = nil, nil, nil
+	res.Links, res.content, res.contentData = nil, nil, nil
+	res.Version = &PostVersion{}
+	for _, p := range []*Post{a, b} {
+		if p.Version != nil && p.Version.ID != "" {
+			res.Version.Parents = append(res.Version.Parents, PostVersionParent{Post: p.ID, Version: p.Version.ID})
+		}
+	}
+
+	typ, err := m.merge("/type", toJSONValue(base.Type), toJSONValue(a.Type), toJSONValue(b.Type))
+	if err != nil {
+		return nil, err
+	}
+	res.Type, _ = typ.v.(string)
+
+	content, err := m.merge("/content", contentValue(base.Content), contentValue(a.Content), contentValue(b.Content))
+	if err != nil {
+		return nil, err
+	}
+	res.Content = content.raw()
+
+	if err := mergeSetField(&res.Mentions, base.Mentions, a.Mentions, b.Mentions); err != nil {
+		return nil, err
+	}
+	if err := mergeSetField(&res.Refs, base.Refs, a.Refs, b.Refs); err != nil {
+		return nil, err
+	}
+	if err := mergeSetField(&res.Attachments, base.Attachments, a.Attachments, b.Attachments); err != nil {
+		return nil, err
+	}
+
+	res.Permissions, err = m.mergePermissions(base.Permissions, a.Permissions, b.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (m *merger) mergePermissions(base, a, b *PostPermissions) (*PostPermissions, error) {
+	var empty PostPermissions
+	if base == nil {
+		base = &empty
+	}
+	if a == nil {
+		a = &empty
+	}
+	if b == nil {
+		b = &empty
+	}
+	res := &PostPermissions{}
+	public, err := m.merge("/permissions/public", toJSONValue(base.PublicFlag), toJSONValue(a.PublicFlag), toJSONValue(b.PublicFlag))
+	if err != nil {
+		return nil, err
+	}
+	if public.ok {
+		flag, _ := public.v.(bool)
+		res.PublicFlag = &flag
+	}
+	if err := mergeSetField(&res.Groups, base.Groups, a.Groups, b.Groups); err != nil {
+		return nil, err
+	}
+	if err := mergeSetField(&res.Entities, base.Entities, a.Entities, b.Entities); err != nil {
+		return nil, err
+	}
+	if res.PublicFlag == nil && len(res.Groups) == 0 && len(res.Entities) == 0 {
+		return nil, nil
+	}
+	return res, nil
+}
+
+// merge does a three-way merge of a JSON value at path.
+func (m *merger) merge(path string, base, a, b jsonValue) (jsonValue, error) {
+	switch {
+	case a.equal(b), base.equal(b):
+		return a, nil
+	case base.equal(a):
+		return b, nil
+	}
+
+	// both sides changed the value, so objects are merged by key
+	ao, aok := a.v.(map[string]interface{})
+	bo, bok := b.v.(map[string]interface{})
+	if !aok || !bok {
+		return m.conflict(path, base, a, b)
+	}
+	baseo, _ := base.v.(map[string]interface{})
+	keys := make(map[string]bool)
+	for _, o := range []map[string]interface{}{baseo, ao, bo} {
+		for k := range o {
+			keys[k] = true
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	res := make(map[string]interface{}, len(keys))
+	for _, k := range sorted {
+		field := func(o map[string]interface{}) jsonValue {
+			v, ok := o[k]
+			return jsonValue{v, ok}
+		}
+		v, err := m.merge(path+"/"+escapePointer(k), field(baseo), field(ao), field(bo))
+		if err != nil {
+			return jsonValue{}, err
+		}
+		if v.ok {
+			res[k] = v.v
+		}
+	}
+	return jsonValue{res, true}, nil
+}
+
+func (m *merger) conflict(path string, base, a, b jsonValue) (jsonValue, error) {
+	c := &MergeConflict{Path: path, Base: base.raw(), A: a.raw(), B: b.raw()}
+	m.conflicts = append(m.conflicts, c)
+	if m.resolve == nil {
+		return a, nil
+	}
+	data, err := m.resolve(c)
+	if err != nil || data == nil {
+		return jsonValue{}, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return jsonValue{}, fmt.Errorf("tent: invalid value resolving %s: %w", path, err)
+	}
+	return jsonValue{v, true}, nil
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func escapePointer(s string) string {
+	return pointerEscaper.Replace(s)
+}
+
+// mergeSetField merges the slices base, a and b as sets and stores the result
+// in res, which must be a pointer to a slice of the same type. Elements are
+// kept unless they were removed on either side, and elements added on either
+// side are included.
+func mergeSetField(res, base, a, b interface{}) error {
+	elems := func(s interface{}) ([]json.RawMessage, map[string]bool) {
+		var list []json.RawMessage
+		data, _ := json.Marshal(s)
+		json.Unmarshal(data, &list)
+		keys := make(map[string]bool, len(list))
+		for _, e := range list {
+			keys[setKey(e)] = true
+		}
+		return list, keys
+	}
+	_, inBase := elems(base)
+	listA, inA := elems(a)
+	listB, inB := elems(b)
+
+	var merged []json.RawMessage
+	seen := make(map[string]bool)
+	add := func(e json.RawMessage) {
+		if k := setKey(e); !seen[k] {
+			seen[k] = true
+			merged = append(merged, e)
+		}
+	}
+	for _, e := range listA {
+		if k := setKey(e); inB[k] || !inBase[k] {
+			add(e)
+		}
+	}
+	for _, e := range listB {
+		if k := setKey(e); !inA[k] && !inBase[k] {
+			add(e)
+		}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, res)
+}
+
+func setKey(e json.RawMessage) string {
+	var v interface{}
+	if json.Unmarshal(e, &v) != nil {
+		return string(e)
+	}
+	data, err := cjson.Marshal(v)
+	if err != nil {
+		return string(e)
+	}
+	return string(data)
+}
@@ -0,0 +1,65 @@
+package tent
+
+import (
+	"encoding/json"
+
+	. "launchpad.net/gocheck"
+)
+
+type MergeSuite struct{}
+
+var _ = Suite(&MergeSuite{})
+
+func mergeTestPosts() (base, a, b *Post) {
+	base = &Post{ID: "p", Type: PostTypeEssay, Version: &PostVersion{ID: "v0"},
+		Content:  []byte(`{"title":"t","body":"x","tags":["a"]}`),
+		Mentions: []PostMention{{Entity: "https://one.example.com"}},
+	}
+	a = &Post{ID: "p", Type: PostTypeEssay, Version: &PostVersion{ID: "va"},
+		Content:     []byte(`{"title":"A","body":"x","tags":["b"]}`),
+		Mentions:    []PostMention{{Entity: "https://one.example.com"}, {Entity: "https://two.example.com"}},
+		Permissions: &PostPermissions{Entities: []string{"https://two.example.com"}},
+	}
+	b = &Post{ID: "p", Type: PostTypeEssay, Version: &PostVersion{ID: "vb"},
+		Content: []byte(`{"title":"t","body":"B","tags":["c"]}`),
+	}
+	return
+}
+
+func (s *MergeSuite) TestMergeVersions(c *C) {
+	post, conflicts, err := MergeVersions(mergeTestPosts())
+	c.Assert(err, IsNil)
+	c.Assert(conflicts, HasLen, 1)
+	c.Assert(conflicts[0].Path, Equals, "/content/tags")
+	c.Assert(string(conflicts[0].Base), Equals, `["a"]`)
+	c.Assert(string(conflicts[0].B), Equals, `["c"]`)
+
+	var content map[string]interface{}
+	c.Assert(json.Unmarshal(post.Content, &content), IsNil)
+	c.Assert(content["title"], Equals, "A")
+	c.Assert(content["body"], Equals, "B")
+	c.Assert(content["tags"], DeepEquals, []interface{}{"b"})
+	c.Assert(post.Mentions, DeepEquals, []PostMention{{Entity: "https://two.example.com"}})
+	c.Assert(post.Permissions.Entities, DeepEquals, []string{"https://two.example.com"})
+	c.Assert(post.Version.Parents, DeepEquals, []PostVersionParent{{Post: "p", Version: "va"}, {Post: "p", Version: "vb"}})
+}
+
+func (s *MergeSuite) TestMergeVersionsResolver(c *C) {
+	base, a, b := mergeTestPosts()
+	post, err := MergeVersionsFunc(base, a, b, func(conflict *MergeConflict) (json.RawMessage, error) {
+		return json.RawMessage(`["b","c"]`), nil
+	})
+	c.Assert(err, IsNil)
+	var content map[string]interface{}
+	c.Assert(json.Unmarshal(post.Content, &content), IsNil)
+	c.Assert(content["tags"], DeepEquals, []interface{}{"b", "c"})
+}
+
+func (s *MergeSuite) TestMergeVersionsResolverInvalidJSON(c *C) {
+	base, a, b := mergeTestPosts()
+	post, err := MergeVersionsFunc(base, a, b, func(conflict *MergeConflict) (json.RawMessage, error) {
+		return json.RawMessage(`["b",`), nil
+	})
+	c.Assert(post, IsNil)
+	c.Assert(err, ErrorMatches, `tent: invalid value resolving /content/tags: .*`)
+}
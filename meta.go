@@ -1,6 +1,7 @@
 package tent
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"strings"
@@ -89,8 +90,21 @@ type MetaProfile struct {
 	AvatarDigest string `json:"avatar_digest,omitempty"`
 }
 
+// GetMetaPost is equivalent to (&Client{}).GetMetaPost.
 func GetMetaPost(url string) (*MetaPost, error) {
-	post, err := GetPost(url)
+	return GetMetaPostContext(context.Background(), url)
+}
+
+func GetMetaPostContext(ctx context.Context, url string) (*MetaPost, error) {
+	return (&Client{}).GetMetaPostContext(ctx, url)
+}
+
+func (client *Client) GetMetaPost(url string) (*MetaPost, error) {
+	return client.GetMetaPostContext(context.Background(), url)
+}
+
+func (client *Client) GetMetaPostContext(ctx context.Context, url string) (*MetaPost, error) {
+	post, err := client.GetPostURLContext(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -109,15 +123,15 @@ func ParseMeta(content []byte, attachments []*PostAttachment) (*MetaPost, error)
 	return meta, err
 }
 
-func getMetaPost(links []string, reqURL *url.URL) (*MetaPost, error) {
+func (client *Client) getMetaPost(ctx context.Context, links []string, reqURL *url.URL) (*MetaPost, error) {
 	for i, l := range links {
 		// replace percent symbols with a private unicode character so that the url doesn't get decoded
 		u, err := url.Parse(strings.Replace(l, "%", "\uFFFE", -1))
 		if err != nil {
 			return nil, err
 		}
-		m, err := GetMetaPost(strings.Replace(reqURL.ResolveReference(u).String(), "%EF%BF%BE", "%", -1))
-		if err != nil && i < len(links)-1 {
+		m, err := client.GetMetaPostContext(ctx, strings.Replace(reqURL.ResolveReference(u).String(), "%EF%BF%BE", "%", -1))
+		if err != nil && i < len(links)-1 && ctx.Err() == nil {
 			continue
 		}
 		return m, err
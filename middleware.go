@@ -0,0 +1,24 @@
+package tent
+
+import "net/http"
+
+// A RoundTripFunc sends an HTTP request and returns the response.
+type RoundTripFunc func(*http.Request) (*http.Response, error)
+
+// A Middleware wraps the RoundTripFunc that sends each request made by
+// a Client. Requests have already been signed when they reach the middleware.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// WithMiddleware adds middleware to the client. The first middleware is the
+// outermost, and sees each request first and each response last.
+func WithMiddleware(m ...Middleware) ClientOption {
+	return func(client *Client) { client.middleware = append(client.middleware, m...) }
+}
+
+func (client *Client) roundTrip() RoundTripFunc {
+	var rt RoundTripFunc = client.httpClient().Do
+	for i := len(client.middleware) - 1; i >= 0; i-- {
+		rt = client.middleware[i](rt)
+	}
+	return rt
+}
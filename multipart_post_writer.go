@@ -35,6 +35,9 @@ func (w *MultipartPostWriter) WritePost(post *Post) error {
 
 func (w *MultipartPostWriter) WriteAttachment(att *PostAttachment) error {
 	part, err := w.m.CreatePart(mimeFileHeader(att.Category+"["+strconv.Itoa(w.i)+"]", att.Name, att.ContentType, att.Data.Len()))
+	if err != nil {
+		return err
+	}
 	_, err = io.Copy(part, att.Data)
 	return err
 }
@@ -0,0 +1,47 @@
+package tent
+
+import (
+	"net/http"
+	"time"
+)
+
+// A ClientOption configures a Client created by NewClient or NewPublicClient.
+type ClientOption func(*Client)
+
+// WithHTTPClient sets the HTTP client used for all requests. It takes
+// precedence over WithTransport.
+func WithHTTPClient(c *http.Client) ClientOption {
+	return func(client *Client) { client.http = c }
+}
+
+// WithTransport sets the RoundTripper used to send requests.
+func WithTransport(t http.RoundTripper) ClientOption {
+	return func(client *Client) { client.transport = t }
+}
+
+// WithTimeout sets the dial, response header and body read timeouts. The dial
+// and header timeouts are not applied if WithHTTPClient or WithTransport are
+// used.
+func WithTimeout(t time.Duration) ClientOption {
+	return func(client *Client) { client.timeout = t }
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(ua string) ClientOption {
+	return func(client *Client) { client.userAgent = ua }
+}
+
+func (client *Client) configure(opts []ClientOption) {
+	client.health = newServerHealth(DefaultServerCooldown)
+	client.limiter = newRateLimiter(0, 1)
+	for _, opt := range opts {
+		opt(client)
+	}
+	if client.http == nil && (client.transport != nil || client.timeout > 0) {
+		transport := client.transport
+		if transport == nil {
+			transport = newTransport(client.timeout)
+		}
+		client.http = &http.Client{Transport: transport}
+	}
+}
@@ -0,0 +1,75 @@
+package tent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "launchpad.net/gocheck"
+)
+
+type OptionsSuite struct{}
+
+var _ = Suite(&OptionsSuite{})
+
+type countingTransport struct {
+	requests int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.requests, 1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (s *OptionsSuite) TestUserAgent(c *C) {
+	var ua atomic.Value
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ua.Store(r.Header.Get("User-Agent"))
+		w.Write([]byte(testPost))
+	}))
+	defer ts.Close()
+
+	_, err := testClient([]string{ts.URL}).GetPost("https://example.com", "a", "", nil)
+	c.Assert(err, IsNil)
+	c.Assert(ua.Load(), Equals, UserAgent)
+
+	_, err = testClient([]string{ts.URL}, WithUserAgent("test/1.0")).GetPost("https://example.com", "a", "", nil)
+	c.Assert(err, IsNil)
+	c.Assert(ua.Load(), Equals, "test/1.0")
+}
+
+func (s *OptionsSuite) TestTimeout(c *C) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+		w.Write([]byte(testPost))
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	start := time.Now()
+	_, err := testClient([]string{ts.URL}, WithTimeout(20*time.Millisecond)).GetPost("https://example.com", "a", "", nil)
+	c.Assert(err, NotNil)
+	c.Assert(time.Since(start) < time.Second, Equals, true)
+}
+
+func (s *OptionsSuite) TestTransport(c *C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testPost))
+	}))
+	defer ts.Close()
+
+	transport := &countingTransport{}
+	_, err := testClient([]string{ts.URL}, WithTransport(transport)).GetPost("https://example.com", "a", "", nil)
+	c.Assert(err, IsNil)
+	c.Assert(atomic.LoadInt32(&transport.requests), Equals, int32(1))
+
+	// WithHTTPClient takes precedence
+	httpTransport := &countingTransport{}
+	client := testClient([]string{ts.URL}, WithTransport(transport), WithHTTPClient(&http.Client{Transport: httpTransport}))
+	_, err = client.GetPost("https://example.com", "a", "", nil)
+	c.Assert(err, IsNil)
+	c.Assert(atomic.LoadInt32(&transport.requests), Equals, int32(1))
+	c.Assert(atomic.LoadInt32(&httpTransport.requests), Equals, int32(1))
+}
@@ -0,0 +1,429 @@
+package tent
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tent/canonical-json-go"
+)
+
+// ErrOutboxSkipped is the error reported for outbox items that were not sent
+// because an earlier item could not be created.
+var ErrOutboxSkipped = errors.New("tent: outbox item skipped after earlier failure")
+
+// An Outbox stores posts in a local directory so that they can be created
+// later, for example once the server is reachable again. Attachment data is
+// copied into the directory when a post is added.
+//
+// Posts are created in the order they were added. An item that can't be
+// created stays in the outbox and blocks the items after it until it is
+// created or removed.
+type Outbox struct {
+	// MaxAttempts is the number of times Flush tries to create each post. If
+	// it is zero, three attempts are made. Each attempt tries every server
+	// once, the client's RetryPolicy is only used for the backoff between
+	// attempts.
+	MaxAttempts int
+
+	client *Client
+	dir    string
+	last   int64
+	// mtx guards the items in dir, flushMtx serializes calls to Flush
+	mtx      sync.Mutex
+	flushMtx sync.Mutex
+}
+
+// An OutboxResult is the outcome of creating one outbox item.
+type OutboxResult struct {
+	ID string
+
+	// Post is the created post, as returned by the server.
+	Post *Post
+
+	// Duplicate is set if the post had already been created by an earlier
+	// attempt whose response was lost.
+	Duplicate bool
+
+	Err error
+}
+
+type outboxItem struct {
+	Post         *Post    `json:"post"`
+	Notification bool     `json:"notification,omitempty"`
+	Files        []string `json:"files,omitempty"`
+
+	// Attempted is set before the post is sent, so that the next attempt
+	// checks if the server already has it.
+	Attempted bool `json:"attempted,omitempty"`
+}
+
+func NewOutbox(client *Client, dir string) (*Outbox, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Outbox{client: client, dir: dir}, nil
+}
+
+// Add stores post in the outbox and returns the ID of the new item. The
+// publish time of the post is set if it is missing, as it is used to find the
+// post on the server if a response is lost.
+func (o *Outbox) Add(post *Post) (string, error) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	p := *post
+	now := &UnixTime{time.Now()}
+	if p.ID == "" && p.PublishedAt == nil {
+		p.PublishedAt = now
+	}
+	p.Version = &PostVersion{}
+	if post.Version != nil {
+		*p.Version = *post.Version
+	}
+	if p.Version.PublishedAt == nil {
+		p.Version.PublishedAt = now
+	}
+	item := &outboxItem{Post: &p, Notification: post.Notification}
+
+	tmp, err := os.MkdirTemp(o.dir, ".tmp-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	p.Attachments = make([]*PostAttachment, len(post.Attachments))
+	item.Files = make([]string, len(post.Attachments))
+	for i, att := range post.Attachments {
+		a := *att
+		a.Data = nil
+		p.Attachments[i] = &a
+		if att.Data == nil {
+			continue
+		}
+		item.Files[i] = "attachment-" + strconv.Itoa(i)
+		if a.Size, a.Digest, err = spoolAttachment(filepath.Join(tmp, item.Files[i]), att.Data); err != nil {
+			return "", err
+		}
+	}
+	if err := writeOutboxItem(tmp, item); err != nil {
+		return "", err
+	}
+
+	id := o.nextID()
+	if err := os.Rename(tmp, filepath.Join(o.dir, id)); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (o *Outbox) nextID() string {
+	n := time.Now().UnixNano()
+	if n <= o.last {
+		n = o.last + 1
+	}
+	o.last = n
+	return fmt.Sprintf("%020d", n)
+}
+
+func spoolAttachment(name string, data io.Reader) (int64, string, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return 0, "", err
+	}
+	h := sha512.New()
+	n, err := io.Copy(io.MultiWriter(f, h), data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return n, "sha512t256-" + hex.EncodeToString(h.Sum(nil)[:32]), err
+}
+
+func writeOutboxItem(dir string, item *outboxItem) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, ".item.json")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, "item.json"))
+}
+
+func (o *Outbox) load(id string) (*outboxItem, error) {
+	data, err := os.ReadFile(filepath.Join(o.dir, id, "item.json"))
+	if err != nil {
+		return nil, err
+	}
+	item := &outboxItem{}
+	if err := json.Unmarshal(data, item); err != nil {
+		return nil, err
+	}
+	item.Post.Notification = item.Notification
+	return item, nil
+}
+
+// Pending returns the IDs of the items in the outbox, oldest first.
+func (o *Outbox) Pending() ([]string, error) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	return o.pending()
+}
+
+func (o *Outbox) pending() ([]string, error) {
+	entries, err := os.ReadDir(o.dir)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			ids = append(ids, e.Name())
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Post returns the post stored in an outbox item, without attachment data.
+func (o *Outbox) Post(id string) (*Post, error) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	item, err := o.load(id)
+	if err != nil {
+		return nil, err
+	}
+	return item.Post, nil
+}
+
+func (o *Outbox) Remove(id string) error {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	return os.RemoveAll(filepath.Join(o.dir, id))
+}
+
+// Flush creates the posts in the outbox in order, removing each one once it
+// has been created, and returns a result for every item. Flush stops at the
+// first item that can't be created; the items after it are reported with
+// ErrOutboxSkipped. The returned error is only set if the outbox could not be
+// read. Posts added while Flush runs are created by the next call.
+func (o *Outbox) Flush(ctx context.Context) ([]*OutboxResult, error) {
+	o.flushMtx.Lock()
+	defer o.flushMtx.Unlock()
+	ids, err := o.Pending()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*OutboxResult, 0, len(ids))
+	for i, id := range ids {
+		res := o.flush(ctx, id)
+		results = append(results, res)
+		if res.Err != nil {
+			for _, id := range ids[i+1:] {
+				results = append(results, &OutboxResult{ID: id, Err: ErrOutboxSkipped})
+			}
+			break
+		}
+	}
+	return results, nil
+}
+
+func (o *Outbox) flush(ctx context.Context, id string) *OutboxResult {
+	res := &OutboxResult{ID: id}
+	dir := filepath.Join(o.dir, id)
+	attempts := o.MaxAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			if err := sleepContext(ctx, o.client.retry().backoff(i-1)); err != nil {
+				res.Err = err
+				break
+			}
+		}
+		item, err := o.loadLocked(id)
+		if err != nil {
+			res.Err = err
+			break
+		}
+		if item.Attempted {
+			// an earlier attempt may have created the post without us
+			// getting the response
+			res.Post, res.Err = o.findExisting(ctx, item)
+			if res.Post != nil {
+				res.Duplicate = true
+				break
+			}
+			if res.Err != nil {
+				continue
+			}
+		} else {
+			item.Attempted = true
+			o.mtx.Lock()
+			res.Err = writeOutboxItem(dir, item)
+			o.mtx.Unlock()
+			if res.Err != nil {
+				break
+			}
+		}
+		res.Post, res.Err = o.create(ctx, dir, item)
+		if res.Err == nil || !outboxRetryable(res.Err) {
+			break
+		}
+	}
+	if res.Err == nil {
+		res.Err = o.Remove(id)
+	}
+	return res
+}
+
+func (o *Outbox) loadLocked(id string) (*outboxItem, error) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	return o.load(id)
+}
+
+// outboxRetryable also retries requests that failed after being sent, as
+// duplicates are detected before the next attempt.
+func outboxRetryable(err error) bool {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return !errors.Is(reqErr.Err, context.Canceled) && !errors.Is(reqErr.Err, context.DeadlineExceeded)
+	}
+	return retryable(err, true)
+}
+
+type outboxFile struct {
+	*os.File
+	size int64
+}
+
+func (f outboxFile) Len() int64 { return f.size }
+
+func (o *Outbox) create(ctx context.Context, dir string, item *outboxItem) (*Post, error) {
+	post := item.Post
+	for i, name := range item.Files {
+		if name == "" {
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		att := post.Attachments[i]
+		att.Data = outboxFile{f, att.Size}
+		att.Size = 0
+		att.Digest = ""
+	}
+	// the client doesn't retry, so that duplicates are checked for before
+	// each attempt
+	if err := o.client.CreatePostContext(withoutRetries(ctx), post); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// findExisting looks for the post on the server by its publish time and
+// returns it if it has the same version as the outbox item.
+func (o *Outbox) findExisting(ctx context.Context, item *outboxItem) (*Post, error) {
+	post := item.Post
+	entity := post.Entity
+	if entity == "" {
+		entity = o.client.Entity
+	}
+
+	if post.ID != "" {
+		page, err := o.client.GetVersionsContext(ctx, entity, post.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range page.Versions {
+			if v.PublishedAt == nil || v.PublishedAt.UnixMillis() != post.Version.PublishedAt.UnixMillis() {
+				continue
+			}
+			p, err := o.client.GetPostContext(ctx, entity, post.ID, v.ID, nil)
+			if err != nil {
+				return nil, err
+			}
+			if sameVersion(post, p.Post) {
+				return p.Post, nil
+			}
+		}
+		return nil, nil
+	}
+
+	q := NewPostsFeedQuery().Types(post.Type).SortBy(PublishedAt).
+		Since(post.PublishedAt.Add(-time.Millisecond), "").
+		Before(post.PublishedAt.Add(time.Millisecond), "")
+	if entity != "" {
+		q.Entities(entity)
+	}
+	page, err := o.client.GetFeedContext(ctx, q, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range page.Posts {
+		if sameVersion(post, p) {
+			p.initAttachments(o.client)
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// sameVersion reports whether remote is local as created by the server, by
+// filling in the fields that the server sets and comparing the versions.
+func sameVersion(local, remote *Post) bool {
+	a, b := *local, *remote
+	a.ID, a.Entity, a.OriginalEntity, a.App = b.ID, b.Entity, b.OriginalEntity, b.App
+	if b.Version == nil {
+		b.Version = &PostVersion{}
+	}
+	va := *local.Version
+	if len(va.Parents) == 0 {
+		va.Parents = b.Version.Parents
+	}
+	a.Version = &va
+	for _, p := range []*Post{&a, &b} {
+		p.Attachments = sortedAttachments(p.Attachments)
+		p.Content = canonicalContent(p.Content)
+	}
+	av, _, err := a.CalculateVersion()
+	if err != nil {
+		return false
+	}
+	bv, _, err := b.CalculateVersion()
+	return err == nil && av == bv
+}
+
+func sortedAttachments(atts []*PostAttachment) []*PostAttachment {
+	s := append([]*PostAttachment(nil), atts...)
+	sort.Slice(s, func(i, j int) bool { return s[i].Digest < s[j].Digest })
+	return s
+}
+
+func canonicalContent(content json.RawMessage) json.RawMessage {
+	var v interface{}
+	if json.Unmarshal(content, &v) != nil {
+		return content
+	}
+	data, err := cjson.Marshal(v)
+	if err != nil {
+		return content
+	}
+	return data
+}
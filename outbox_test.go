@@ -0,0 +1,158 @@
+package tent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "launchpad.net/gocheck"
+)
+
+type OutboxSuite struct{}
+
+var _ = Suite(&OutboxSuite{})
+
+func (s *OutboxSuite) TestFlushDetectsDuplicate(c *C) {
+	var creates int32
+	var created []*Post
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			json.NewEncoder(w).Encode(&PostListPage{Posts: created})
+			return
+		}
+		post := &Post{}
+		c.Assert(json.NewDecoder(r.Body).Decode(post), IsNil)
+		post.ID = string(rune('a' + len(created)))
+		post.Entity = "https://example.com"
+		created = append(created, post)
+		if atomic.AddInt32(&creates, 1) == 1 {
+			// lose the response to the first post
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		json.NewEncoder(w).Encode(&PostEnvelope{Post: post})
+	}))
+	defer ts.Close()
+
+	client := testClient([]string{ts.URL})
+	client.Entity = "https://example.com"
+	client.Servers[0].URLs.PostsFeed = ts.URL + "/posts"
+	outbox, err := NewOutbox(client, c.MkDir())
+	c.Assert(err, IsNil)
+
+	for _, text := range []string{"one", "two"} {
+		_, err := outbox.Add(&Post{Type: "https://tent.io/types/status/v0#", Content: []byte(`{"text":"` + text + `"}`)})
+		c.Assert(err, IsNil)
+	}
+	results, err := outbox.Flush(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(results, HasLen, 2)
+	c.Assert(results[0].Err, IsNil)
+	c.Assert(results[0].Duplicate, Equals, true)
+	c.Assert(results[0].Post.ID, Equals, "a")
+	c.Assert(results[1].Err, IsNil)
+	c.Assert(results[1].Duplicate, Equals, false)
+	c.Assert(results[1].Post.ID, Equals, "b")
+	c.Assert(atomic.LoadInt32(&creates), Equals, int32(2))
+
+	pending, err := outbox.Pending()
+	c.Assert(err, IsNil)
+	c.Assert(pending, HasLen, 0)
+}
+
+func (s *OutboxSuite) TestFlushAttempts(c *C) {
+	var creates int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			json.NewEncoder(w).Encode(&PostListPage{})
+			return
+		}
+		atomic.AddInt32(&creates, 1)
+		w.WriteHeader(503)
+	}))
+	defer ts.Close()
+
+	client := testClient([]string{ts.URL})
+	client.Servers[0].URLs.PostsFeed = ts.URL + "/posts"
+	outbox, err := NewOutbox(client, c.MkDir())
+	c.Assert(err, IsNil)
+	outbox.MaxAttempts = 2
+	_, err = outbox.Add(&Post{Type: "https://tent.io/types/status/v0#"})
+	c.Assert(err, IsNil)
+
+	results, err := outbox.Flush(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(results[0].Err, NotNil)
+	// the client's retries aren't multiplied by the outbox attempts
+	c.Assert(atomic.LoadInt32(&creates), Equals, int32(2))
+}
+
+func (s *OutboxSuite) TestAddDuringFlush(c *C) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		post := &Post{}
+		c.Assert(json.NewDecoder(r.Body).Decode(post), IsNil)
+		post.ID = "a"
+		json.NewEncoder(w).Encode(&PostEnvelope{Post: post})
+	}))
+	defer ts.Close()
+
+	outbox, err := NewOutbox(testClient([]string{ts.URL}), c.MkDir())
+	c.Assert(err, IsNil)
+	_, err = outbox.Add(&Post{Type: "https://tent.io/types/status/v0#"})
+	c.Assert(err, IsNil)
+
+	flushed := make(chan []*OutboxResult)
+	go func() {
+		results, _ := outbox.Flush(context.Background())
+		flushed <- results
+	}()
+	<-started
+	added := make(chan error)
+	go func() {
+		_, err := outbox.Add(&Post{Type: "https://tent.io/types/status/v0#"})
+		added <- err
+	}()
+	select {
+	case err := <-added:
+		c.Assert(err, IsNil)
+	case <-time.After(time.Second):
+		c.Fatal("Add blocked by Flush")
+	}
+	close(release)
+
+	results := <-flushed
+	c.Assert(results, HasLen, 1)
+	c.Assert(results[0].Err, IsNil)
+	pending, err := outbox.Pending()
+	c.Assert(err, IsNil)
+	c.Assert(pending, HasLen, 1)
+}
+
+func (s *OutboxSuite) TestAddSpoolsAttachments(c *C) {
+	outbox, err := NewOutbox(NewPublicClient(), c.MkDir())
+	c.Assert(err, IsNil)
+	id, err := outbox.Add(&Post{
+		Type:        "https://tent.io/types/photo/v0#",
+		Attachments: []*PostAttachment{{Name: "a.txt", Category: "photo", ContentType: "text/plain", Data: lenReader{bytes.NewReader([]byte("hello"))}}},
+	})
+	c.Assert(err, IsNil)
+
+	post, err := outbox.Post(id)
+	c.Assert(err, IsNil)
+	c.Assert(post.PublishedAt, NotNil)
+	c.Assert(post.Attachments[0].Size, Equals, int64(5))
+	c.Assert(post.Attachments[0].Digest, Matches, "sha512t256-[0-9a-f]{64}")
+}
+
+type lenReader struct{ *bytes.Reader }
+
+func (r lenReader) Len() int64 { return int64(r.Reader.Len()) }
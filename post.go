@@ -1,6 +1,7 @@
 package tent
 
 import (
+	"context"
 	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/hex"
@@ -143,6 +144,10 @@ type Post struct {
 	Links []link.Link `json:"-"`
 
 	Notification bool `json:"-"`
+
+	// decoded content, valid while Content is unchanged
+	content     interface{}
+	contentData []byte
 }
 
 type PostEnvelope struct {
@@ -156,9 +161,20 @@ var ErrMissingCredentialsLink = errors.New("tent: missing credentials link")
 
 type PostRequest struct {
 	MaxRefs int
+
+	// VerifyVersion rejects the post and its refs if their versions don't
+	// match their contents.
+	VerifyVersion bool
 }
 
 func (client *Client) GetPost(entity, id, version string, r *PostRequest) (*PostEnvelope, error) {
+	return client.GetPostContext(context.Background(), entity, id, version, r)
+}
+
+func (client *Client) GetPostContext(ctx context.Context, entity, id, version string, r *PostRequest) (*PostEnvelope, error) {
+	ctx = withEndpoint(ctx, EndpointPost)
+	// a specific version of a post never changes
+	ctx = withCacheable(ctx, version != "")
 	post := &PostEnvelope{}
 	header := make(http.Header)
 	header.Set("Accept", MediaTypePost)
@@ -169,29 +185,50 @@ func (client *Client) GetPost(entity, id, version string, r *PostRequest) (*Post
 		}
 		return u
 	}
-	_, err := client.requestJSON("GET", urlFunc, header, nil, post)
+	_, err := client.requestJSON(ctx, "GET", urlFunc, header, nil, post)
 	if err != nil || post.Post == nil {
 		if err == nil {
 			err = newResponseError(ErrBadData, nil)
 		}
 		return nil, err
 	}
+	if err := client.verifyEnvelope(post, r); err != nil {
+		return nil, err
+	}
 	post.Post.initAttachments(client)
 	for _, p := range post.Refs {
 		p.initAttachments(client)
 	}
+	client.decodeEnvelopeContent(post)
 	return post, err
 }
 
+// GetPost is equivalent to (&Client{}).GetPostURL.
 func GetPost(url string) (*PostEnvelope, error) {
-	req, err := NewRequest("GET", url, nil, nil)
+	return GetPostContext(context.Background(), url)
+}
+
+func GetPostContext(ctx context.Context, url string) (*PostEnvelope, error) {
+	return (&Client{}).GetPostURLContext(ctx, url)
+}
+
+// GetPostURL fetches the post at url without credentials using the client's
+// HTTP configuration.
+func (client *Client) GetPostURL(url string) (*PostEnvelope, error) {
+	return client.GetPostURLContext(context.Background(), url)
+}
+
+func (client *Client) GetPostURLContext(ctx context.Context, url string) (*PostEnvelope, error) {
+	ctx = withEndpoint(ctx, EndpointPost)
+	ctx = withCacheable(ctx, false)
+	req, err := NewRequestContext(ctx, "GET", url, nil, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Accept", MediaTypePost)
-	res, err := HTTP.Do(req)
+	res, err := client.do(req)
 	if err != nil {
-		return nil, newRequestError(err, req)
+		return nil, err
 	}
 	defer res.Body.Close()
 	if res.StatusCode != 200 {
@@ -199,23 +236,31 @@ func GetPost(url string) (*PostEnvelope, error) {
 	}
 
 	post := &PostEnvelope{}
-	if ok := timeoutRead(res.Body, func() {
-		err = json.NewDecoder(res.Body).Decode(post)
-	}); !ok {
-		return nil, newResponseError(ErrReadTimeout, res)
+	err = client.readBody(res, func(body io.Reader) error {
+		return json.NewDecoder(body).Decode(post)
+	})
+	if err != nil {
+		return nil, err
 	}
 	if post.Post == nil {
 		return nil, newResponseError(ErrBadData, res)
 	}
-	c := &Client{}
-	post.Post.initAttachments(c)
+	if err := client.verifyEnvelope(post, nil); err != nil {
+		return nil, err
+	}
+	post.Post.initAttachments(client)
 	for _, p := range post.Refs {
-		p.initAttachments(c)
+		p.initAttachments(client)
 	}
+	client.decodeEnvelopeContent(post)
 	return post, err
 }
 
 func (post *Post) LinkedCredentials() (*hawk.Credentials, *Post, error) {
+	return post.LinkedCredentialsContext(context.Background())
+}
+
+func (post *Post) LinkedCredentialsContext(ctx context.Context) (*hawk.Credentials, *Post, error) {
 	var credsPostURL string
 	for _, l := range post.Links {
 		if l.Rel == RelCredentials {
@@ -226,7 +271,7 @@ func (post *Post) LinkedCredentials() (*hawk.Credentials, *Post, error) {
 	if credsPostURL == "" {
 		return nil, nil, ErrMissingCredentialsLink
 	}
-	p, err := GetPost(credsPostURL)
+	p, err := GetPostContext(ctx, credsPostURL)
 	if err != nil {
 		return nil, nil, err
 	}
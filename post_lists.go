@@ -1,6 +1,7 @@
 package tent
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/url"
@@ -39,40 +40,70 @@ type PageLinks struct {
 	client  *Client
 }
 
-func (links *PageLinks) get(query string) (*PostListPage, error) {
+func (links *PageLinks) get(ctx context.Context, query string) (*PostListPage, error) {
 	if query == "" {
 		return nil, ErrNoPage
 	}
+	ctx = withEndpoint(ctx, listEndpoint(links.accept))
 	page := &PostListPage{Links: PageLinks{accept: links.accept, baseURL: links.baseURL, client: links.client}}
 	links.baseURL = strings.SplitN(links.baseURL, "?", 2)[0] + query
 	header := make(http.Header)
 	header.Set("Accept", links.accept)
-	_, err := links.client.requestJSONURL("GET", links.baseURL, header, nil, page)
+	_, err := links.client.requestJSONURL(ctx, "GET", links.baseURL, header, nil, page)
 	if err != nil {
 		return nil, err
 	}
+	if err := links.client.verifyPage(page); err != nil {
+		return nil, err
+	}
+	links.client.decodePageContent(page)
 	return page, nil
 }
 
 func (client *Client) GetFeed(q *PostsFeedQuery, r *PageRequest) (*PostListPage, error) {
-	return client.getPostListPage("", "", "", MediaTypePostsFeed, r, q.Values)
+	return client.GetFeedContext(context.Background(), q, r)
+}
+
+func (client *Client) GetFeedContext(ctx context.Context, q *PostsFeedQuery, r *PageRequest) (*PostListPage, error) {
+	return client.getPostListPage(ctx, "", "", "", MediaTypePostsFeed, r, q.Values)
 }
 
 func (client *Client) GetVersions(entity, post string, r *PageRequest) (*PostListPage, error) {
-	return client.getPostListPage(entity, post, "", MediaTypePostVersions, r, nil)
+	return client.GetVersionsContext(context.Background(), entity, post, r)
+}
+
+func (client *Client) GetVersionsContext(ctx context.Context, entity, post string, r *PageRequest) (*PostListPage, error) {
+	return client.getPostListPage(ctx, entity, post, "", MediaTypePostVersions, r, nil)
 }
 
 func (client *Client) GetChildren(entity, post, version string, r *PageRequest) (*PostListPage, error) {
-	return client.getPostListPage(entity, post, version, MediaTypePostChildren, r, nil)
+	return client.GetChildrenContext(context.Background(), entity, post, version, r)
+}
+
+func (client *Client) GetChildrenContext(ctx context.Context, entity, post, version string, r *PageRequest) (*PostListPage, error) {
+	return client.getPostListPage(ctx, entity, post, version, MediaTypePostChildren, r, nil)
 }
 
 func (client *Client) GetMentions(entity, post string, r *PageRequest) (*PostListPage, error) {
-	return client.getPostListPage(entity, post, "", MediaTypePostMentions, r, nil)
+	return client.GetMentionsContext(context.Background(), entity, post, r)
+}
+
+func (client *Client) GetMentionsContext(ctx context.Context, entity, post string, r *PageRequest) (*PostListPage, error) {
+	return client.getPostListPage(ctx, entity, post, "", MediaTypePostMentions, r, nil)
 }
 
 var ErrNoPage = errors.New("tent: the requested page does not exist")
 
-func (client *Client) getPostListPage(entity, post, version, mediaType string, r *PageRequest, query url.Values) (*PostListPage, error) {
+func listEndpoint(mediaType string) string {
+	if mediaType == MediaTypePostsFeed {
+		return EndpointPostsFeed
+	}
+	return EndpointPost
+}
+
+func (client *Client) getPostListPage(ctx context.Context, entity, post, version, mediaType string, r *PageRequest, query url.Values) (*PostListPage, error) {
+	ctx = withEndpoint(ctx, listEndpoint(mediaType))
+	ctx = withCacheable(ctx, false)
 	header := make(http.Header)
 	header.Set("Accept", mediaType)
 	if r != nil && r.ETag != "" {
@@ -112,12 +143,13 @@ func (client *Client) getPostListPage(entity, post, version, mediaType string, r
 	}
 	if r != nil && r.CountOnly {
 		var err error
-		page.Header, err = client.requestCount(urlFunc, header)
+		page.Header, err = client.requestCount(ctx, urlFunc, header)
 		return nil, err
 	}
-	resHeader, err := client.requestJSON("GET", urlFunc, header, nil, page)
+	resHeader, err := client.requestJSON(ctx, "GET", urlFunc, header, nil, page)
 	if err != nil {
-		if resErr, ok := err.(*ResponseError); ok && resErr.Type == ErrBadStatusCode && resErr.Response.StatusCode == 304 {
+		var resErr *ResponseError
+		if errors.As(err, &resErr) && resErr.Type == ErrBadStatusCode && resErr.Response.StatusCode == 304 {
 			page.Header.ETag = resHeader.Get("Etag")
 			page.Header.NotModified = true
 			return page, nil
@@ -127,10 +159,27 @@ func (client *Client) getPostListPage(entity, post, version, mediaType string, r
 	page.Links.client = client
 	page.Links.accept = mediaType
 	page.Header.ETag = resHeader.Get("Etag")
+	if err := client.verifyPage(page); err != nil {
+		return nil, err
+	}
+	client.decodePageContent(page)
 	return page, nil
 }
 
-func (f *PostListPage) First() (*PostListPage, error) { return f.Links.get(f.Links.First) }
-func (f *PostListPage) Prev() (*PostListPage, error)  { return f.Links.get(f.Links.Prev) }
-func (f *PostListPage) Next() (*PostListPage, error)  { return f.Links.get(f.Links.Next) }
-func (f *PostListPage) Last() (*PostListPage, error)  { return f.Links.get(f.Links.Last) }
+func (f *PostListPage) First() (*PostListPage, error) { return f.FirstContext(context.Background()) }
+func (f *PostListPage) Prev() (*PostListPage, error)  { return f.PrevContext(context.Background()) }
+func (f *PostListPage) Next() (*PostListPage, error)  { return f.NextContext(context.Background()) }
+func (f *PostListPage) Last() (*PostListPage, error)  { return f.LastContext(context.Background()) }
+
+func (f *PostListPage) FirstContext(ctx context.Context) (*PostListPage, error) {
+	return f.Links.get(ctx, f.Links.First)
+}
+func (f *PostListPage) PrevContext(ctx context.Context) (*PostListPage, error) {
+	return f.Links.get(ctx, f.Links.Prev)
+}
+func (f *PostListPage) NextContext(ctx context.Context) (*PostListPage, error) {
+	return f.Links.get(ctx, f.Links.Next)
+}
+func (f *PostListPage) LastContext(ctx context.Context) (*PostListPage, error) {
+	return f.Links.get(ctx, f.Links.Last)
+}
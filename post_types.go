@@ -0,0 +1,187 @@
+package tent
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+const (
+	PostTypeStatus      = "https://tent.io/types/status/v0#"
+	PostTypeStatusReply = "https://tent.io/types/status/v0#reply"
+	PostTypeEssay       = "https://tent.io/types/essay/v0#"
+	PostTypePhoto       = "https://tent.io/types/photo/v0#"
+	PostTypeAlbum       = "https://tent.io/types/album/v0#"
+	PostTypeRepost      = "https://tent.io/types/repost/v0#"
+	PostTypeFavorite    = "https://tent.io/types/favorite/v0#"
+)
+
+var ErrWrongPostType = errors.New("tent: post is not of the requested type")
+
+func init() {
+	RegisterContentType(PostTypeStatus, Status{})
+	RegisterContentType(PostTypeEssay, Essay{})
+	RegisterContentType(PostTypePhoto, Photo{})
+	RegisterContentType(PostTypeAlbum, Album{})
+	RegisterContentType(PostTypeRepost, Repost{})
+	RegisterContentType(PostTypeFavorite, Favorite{})
+}
+
+type Status struct {
+	Text     string    `json:"text"`
+	Location *Location `json:"location,omitempty"`
+}
+
+type Location struct {
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Altitude  *float64 `json:"altitude,omitempty"`
+	Name      string   `json:"name,omitempty"`
+}
+
+type Essay struct {
+	Title   string   `json:"title,omitempty"`
+	Excerpt string   `json:"excerpt,omitempty"`
+	Body    string   `json:"body"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// Photo is the content of a photo post. The image itself is the first
+// attachment of the post.
+type Photo struct {
+	Caption string   `json:"caption,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// Album is the content of an album post. The photos in the album are refs of
+// the post.
+type Album struct {
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Cover       *PostRef `json:"cover,omitempty"`
+}
+
+type Repost struct {
+	Comment string `json:"comment,omitempty"`
+}
+
+type Favorite struct{}
+
+// NewStatusPost returns a status post with the given permissions, which are
+// public if nil. If one of the mentions is of a post, the status is a reply and
+// has the PostTypeStatusReply type.
+func NewStatusPost(status *Status, permissions *PostPermissions, mentions ...PostMention) *Post {
+	data, _ := json.Marshal(status)
+	post := &Post{Type: PostTypeStatus, Content: data, Permissions: permissions, Mentions: mentions}
+	for _, m := range mentions {
+		if m.Post != "" {
+			post.Type = PostTypeStatusReply
+			break
+		}
+	}
+	return post
+}
+
+// NewEssayPost returns an essay post with the given permissions, which are
+// public if nil.
+func NewEssayPost(essay *Essay, permissions *PostPermissions, mentions ...PostMention) *Post {
+	data, _ := json.Marshal(essay)
+	return &Post{Type: PostTypeEssay, Content: data, Permissions: permissions, Mentions: mentions}
+}
+
+// NewPhotoPost returns a photo post with image, if not nil, as its only
+// attachment.
+func NewPhotoPost(photo *Photo, image *PostAttachment, permissions *PostPermissions, mentions ...PostMention) *Post {
+	data, _ := json.Marshal(photo)
+	post := &Post{Type: PostTypePhoto, Content: data, Permissions: permissions, Mentions: mentions}
+	if image != nil {
+		post.Attachments = []*PostAttachment{image}
+	}
+	return post
+}
+
+// NewAlbumPost returns an album post that refs photos.
+func NewAlbumPost(album *Album, photos []*Post, permissions *PostPermissions, mentions ...PostMention) *Post {
+	data, _ := json.Marshal(album)
+	post := &Post{Type: PostTypeAlbum, Content: data, Permissions: permissions, Mentions: mentions}
+	for _, p := range photos {
+		post.Refs = append(post.Refs, PostRef{Entity: p.Entity, Post: p.ID, Type: p.Type})
+	}
+	return post
+}
+
+// NewRepostPost returns a repost of post, which is referenced and mentioned.
+// The type fragment is the type of the reposted post.
+func NewRepostPost(repost *Repost, post *Post) *Post {
+	data, _ := json.Marshal(repost)
+	return reactionPost(PostTypeRepost, data, post)
+}
+
+// NewFavoritePost returns a post marking post as a favorite.
+func NewFavoritePost(post *Post) *Post {
+	return reactionPost(PostTypeFavorite, []byte("{}"), post)
+}
+
+func reactionPost(typ string, content []byte, post *Post) *Post {
+	return &Post{
+		Type:     typ + TypeBase(post.Type),
+		Content:  content,
+		Refs:     []PostRef{{Entity: post.Entity, Post: post.ID, Type: post.Type}},
+		Mentions: []PostMention{{Entity: post.Entity, Post: post.ID, Type: post.Type}},
+	}
+}
+
+func (post *Post) Status() (*Status, error) {
+	v, err := post.typedContent(PostTypeStatus)
+	if s, ok := v.(*Status); ok || err != nil {
+		return s, err
+	}
+	return nil, ErrWrongPostType
+}
+
+func (post *Post) Essay() (*Essay, error) {
+	v, err := post.typedContent(PostTypeEssay)
+	if e, ok := v.(*Essay); ok || err != nil {
+		return e, err
+	}
+	return nil, ErrWrongPostType
+}
+
+func (post *Post) Photo() (*Photo, error) {
+	v, err := post.typedContent(PostTypePhoto)
+	if p, ok := v.(*Photo); ok || err != nil {
+		return p, err
+	}
+	return nil, ErrWrongPostType
+}
+
+func (post *Post) Album() (*Album, error) {
+	v, err := post.typedContent(PostTypeAlbum)
+	if a, ok := v.(*Album); ok || err != nil {
+		return a, err
+	}
+	return nil, ErrWrongPostType
+}
+
+func (post *Post) Repost() (*Repost, error) {
+	v, err := post.typedContent(PostTypeRepost)
+	if r, ok := v.(*Repost); ok || err != nil {
+		return r, err
+	}
+	return nil, ErrWrongPostType
+}
+
+func (post *Post) Favorite() (*Favorite, error) {
+	v, err := post.typedContent(PostTypeFavorite)
+	if f, ok := v.(*Favorite); ok || err != nil {
+		return f, err
+	}
+	return nil, ErrWrongPostType
+}
+
+// typedContent decodes the post content if the post has the type typ.
+func (post *Post) typedContent(typ string) (interface{}, error) {
+	if TypeBase(post.Type) != TypeBase(typ) {
+		return nil, ErrWrongPostType
+	}
+	return post.DecodeContent()
+}
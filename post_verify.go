@@ -0,0 +1,102 @@
+package tent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var ErrVersionMismatch = errors.New("tent: post version does not match its contents")
+
+// A VersionError is returned when the version ID of a post doesn't match the
+// version calculated from its contents.
+type VersionError struct {
+	Entity     string
+	Post       string
+	Version    string
+	Calculated string
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("tent: post %s/%s has version %q, calculated %q", e.Entity, e.Post, e.Version, e.Calculated)
+}
+
+func (e *VersionError) Is(target error) bool { return target == ErrVersionMismatch }
+
+// VerifyVersion recalculates the version ID of the post and checks that it
+// matches Version.ID.
+func (post *Post) VerifyVersion() error {
+	verr := &VersionError{Entity: post.Entity, Post: post.ID}
+	if post.Version == nil || post.Version.ID == "" {
+		return verr
+	}
+	verr.Version = post.Version.ID
+	id, _, err := post.CalculateVersion()
+	if err != nil {
+		return err
+	}
+	if id != post.Version.ID {
+		verr.Calculated = id
+		return verr
+	}
+	return nil
+}
+
+// WithVersionVerification makes the client check the version of every post it
+// receives with VerifyVersion, and reject posts that don't match.
+func WithVersionVerification() ClientOption {
+	return func(client *Client) { client.verifyVersions = true }
+}
+
+// verifyPost checks the version and content schema of a received post, if
+// enabled.
+func (client *Client) verifyPost(post *Post, r *PostRequest) error {
+	if client.verifyVersions || r != nil && r.VerifyVersion {
+		if err := post.VerifyVersion(); err != nil {
+			return err
+		}
+	}
+	if client.validateSchemas {
+		return post.ValidateContent()
+	}
+	return nil
+}
+
+func (client *Client) verifyEnvelope(env *PostEnvelope, r *PostRequest) error {
+	if err := client.verifyPost(env.Post, r); err != nil {
+		return err
+	}
+	for i := range env.Refs {
+		if err := client.verifyPost(&env.Refs[i], r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (client *Client) verifyPage(page *PostListPage) error {
+	for _, p := range page.Posts {
+		if err := client.verifyPost(p, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadNotification decodes the post sent in a notification request from
+// a server. The version and content of the post are checked if version or
+// schema verification are enabled.
+func (client *Client) ReadNotification(req *http.Request) (*Post, error) {
+	post := &Post{}
+	if err := json.NewDecoder(req.Body).Decode(post); err != nil {
+		return nil, err
+	}
+	post.Notification = true
+	if err := client.verifyPost(post, nil); err != nil {
+		return nil, err
+	}
+	post.initAttachments(client)
+	client.decodePostContent(post)
+	return post, nil
+}
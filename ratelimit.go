@@ -0,0 +1,145 @@
+package tent
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WithRateLimit limits the rate of requests sent to each server host to r
+// requests per second, allowing bursts of up to burst requests.
+func WithRateLimit(r float64, burst int) ClientOption {
+	return func(client *Client) { client.limiter = newRateLimiter(r, burst) }
+}
+
+// rateLimiter keeps a token bucket for each server host. Hosts that respond
+// with Retry-After are paused until the given time, up to the MaxBackoff of the
+// client's RetryPolicy, even if no rate is set.
+type rateLimiter struct {
+	rate  float64
+	burst int
+
+	mtx     sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter(r float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{rate: r, burst: burst, buckets: make(map[string]*tokenBucket)}
+}
+
+func (l *rateLimiter) bucket(host string) *tokenBucket {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	b, ok := l.buckets[host]
+	if !ok {
+		b = &tokenBucket{rate: l.rate, burst: float64(l.burst), tokens: float64(l.burst), last: time.Now()}
+		l.buckets[host] = b
+	}
+	return b
+}
+
+// wait blocks until a request may be sent to host or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context, host string) error {
+	if l == nil {
+		return nil
+	}
+	b := l.bucket(host)
+	if err := sleepContext(ctx, b.reserve(time.Now())); err != nil {
+		b.cancel()
+		return err
+	}
+	return nil
+}
+
+func (l *rateLimiter) pause(host string, d time.Duration) {
+	if l == nil || d <= 0 {
+		return
+	}
+	l.bucket(host).pause(time.Now().Add(d))
+}
+
+type tokenBucket struct {
+	mtx         sync.Mutex
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// reserve takes a token and returns how long the caller must wait before
+// using it.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	var wait time.Duration
+	if b.rate > 0 {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+		b.tokens--
+		if b.tokens < 0 {
+			wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+		}
+	}
+	if pause := b.pausedUntil.Sub(now); pause > wait {
+		wait = pause
+	}
+	return wait
+}
+
+// cancel returns a reserved token that was not used.
+func (b *tokenBucket) cancel() {
+	b.mtx.Lock()
+	if b.rate > 0 {
+		b.tokens++
+	}
+	b.mtx.Unlock()
+}
+
+func (b *tokenBucket) pause(until time.Time) {
+	b.mtx.Lock()
+	if until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+	b.mtx.Unlock()
+}
+
+// RetryAfter returns the delay requested by the Retry-After header of a 429 or
+// 503 response.
+func (e *ResponseError) RetryAfter() (time.Duration, bool) {
+	if e.Type != ErrBadStatusCode || e.Response == nil {
+		return 0, false
+	}
+	if e.Response.StatusCode != 429 && e.Response.StatusCode != 503 {
+		return 0, false
+	}
+	return parseRetryAfter(e.Response.Header.Get("Retry-After"), time.Now())
+}
+
+func parseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(header); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := http.ParseTime(header)
+	if err != nil {
+		return 0, false
+	}
+	if d := t.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
@@ -0,0 +1,187 @@
+package tent
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+)
+
+// A RetryPolicy controls how many times a request is attempted and how long to
+// wait between attempts. Only transient failures are retried: dial errors,
+// timeouts and 429, 502, 503 and 504 responses. Requests that aren't
+// idempotent, like creating a post, are only retried after dial errors and 429
+// and 503 responses with a Retry-After header. The delay requested by
+// a Retry-After header is honored if it is longer than the backoff, up to
+// MaxBackoff. If a server asks for a longer delay the request is not retried
+// and the *ResponseError is returned.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts across all servers. If it
+	// is zero, each server is tried at least once and up to three attempts are
+	// made.
+	MaxAttempts int
+
+	// MinBackoff is the delay before the first retry against a server that has
+	// already been tried. It doubles after each retry up to MaxBackoff, and
+	// a random jitter of up to half the delay is subtracted.
+	MinBackoff time.Duration
+	// MaxBackoff is also the longest Retry-After delay that is waited for. If
+	// it is zero, the default of five seconds is used for Retry-After.
+	MaxBackoff time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: 5 * time.Second}
+
+// WithRetryPolicy sets the retry policy used by Request and the client's
+// request methods.
+func WithRetryPolicy(p RetryPolicy) ClientOption {
+	return func(client *Client) { client.retryPolicy = &p }
+}
+
+func (client *Client) retry() *RetryPolicy {
+	if client.retryPolicy != nil {
+		return client.retryPolicy
+	}
+	return &DefaultRetryPolicy
+}
+
+type noRetriesKey struct{}
+
+// withoutRetries makes requests try each server once, without backing off and
+// starting over, for callers that retry on their own.
+func withoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetriesKey{}, true)
+}
+
+func noRetries(ctx context.Context) bool {
+	v, _ := ctx.Value(noRetriesKey{}).(bool)
+	return v
+}
+
+func (p *RetryPolicy) attempts(servers int) int {
+	if p.MaxAttempts > 0 {
+		return p.MaxAttempts
+	}
+	if servers > 3 {
+		return servers
+	}
+	return 3
+}
+
+func (p *RetryPolicy) maxRetryAfter() time.Duration {
+	if p.MaxBackoff > 0 {
+		return p.MaxBackoff
+	}
+	return DefaultRetryPolicy.MaxBackoff
+}
+
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	d := p.MinBackoff
+	for i := 0; i < retry && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d - time.Duration(rand.Int63n(int64(d)/2+1))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// afterDone calls f in its own goroutine once ctx is done, unless stop is
+// called first. stop must be called exactly once.
+func afterDone(ctx context.Context, f func()) (stop func()) {
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			f()
+		case <-stopped:
+		}
+	}()
+	return func() { close(stopped) }
+}
+
+func retryAfter(err error) (time.Duration, bool) {
+	var resErr *ResponseError
+	if errors.As(err, &resErr) {
+		return resErr.RetryAfter()
+	}
+	return 0, false
+}
+
+// retryable reports whether err is a transient failure that may succeed if the
+// request is attempted again. If idempotent is false only failures that
+// guarantee the request was not processed are retryable: dial errors, and 429
+// and 503 responses with Retry-After, which are sent by the origin server.
+// A bare 503 may come from a proxy after the request was processed.
+func retryable(err error, idempotent bool) bool {
+	var resErr *ResponseError
+	if errors.As(err, &resErr) {
+		switch resErr.Type {
+		case ErrReadTimeout:
+			return idempotent
+		case ErrBadStatusCode:
+			switch resErr.Response.StatusCode {
+			case 429, 503:
+				_, ok := resErr.RetryAfter()
+				return idempotent || ok
+			case 502, 504:
+				return idempotent
+			}
+		}
+		return false
+	}
+
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) {
+		return false
+	}
+	if errors.Is(reqErr.Err, context.Canceled) || errors.Is(reqErr.Err, context.DeadlineExceeded) {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(reqErr.Err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var netErr net.Error
+	return idempotent && errors.As(reqErr.Err, &netErr) && netErr.Timeout()
+}
+
+func idempotentMethod(method string) bool {
+	return method != "POST" && method != "PATCH"
+}
+
+// AttemptsError is returned when a request was attempted more than once and
+// every attempt failed. Errors holds the error from each attempt in order.
+type AttemptsError struct {
+	Errors []error
+}
+
+func (e *AttemptsError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "tent: all attempts failed: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the error from the last attempt.
+func (e *AttemptsError) Unwrap() error { return e.Errors[len(e.Errors)-1] }
@@ -0,0 +1,180 @@
+package tent
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "launchpad.net/gocheck"
+)
+
+type RetrySuite struct{}
+
+var _ = Suite(&RetrySuite{})
+
+func (s *RetrySuite) TestRetryTransientFailure(c *C) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(503)
+			return
+		}
+		w.Write([]byte(testPost))
+	}))
+	defer ts.Close()
+
+	post, err := testClient([]string{ts.URL}).GetPost("https://example.com", "a", "", nil)
+	c.Assert(err, IsNil)
+	c.Assert(post.Post.ID, Equals, "a")
+	c.Assert(requests, Equals, 3)
+}
+
+func (s *RetrySuite) TestNoRetryPermanentFailure(c *C) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(404)
+	}))
+	defer ts.Close()
+
+	_, err := testClient([]string{ts.URL, ts.URL}).GetPost("https://example.com", "a", "", nil)
+	resErr, ok := err.(*ResponseError)
+	c.Assert(ok, Equals, true)
+	c.Assert(resErr.Response.StatusCode, Equals, 404)
+	c.Assert(requests, Equals, 1)
+}
+
+func (s *RetrySuite) TestRetryKeepsAttemptErrors(c *C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(502)
+	}))
+	defer ts.Close()
+
+	_, err := testClient([]string{ts.URL, ts.URL}).GetPost("https://example.com", "a", "", nil)
+	attemptsErr, ok := err.(*AttemptsError)
+	c.Assert(ok, Equals, true)
+	c.Assert(attemptsErr.Errors, HasLen, 3)
+	var resErr *ResponseError
+	c.Assert(errors.As(err, &resErr), Equals, true)
+	c.Assert(resErr.Response.StatusCode, Equals, 502)
+}
+
+func (s *RetrySuite) TestNoRetryUnsafePost(c *C) {
+	for _, status := range []int{504, 503} {
+		var requests int
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(status)
+		}))
+
+		err := testClient([]string{ts.URL}).CreatePost(&Post{Type: "https://tent.io/types/status/v0#"})
+		ts.Close()
+		c.Assert(err, NotNil)
+		c.Assert(requests, Equals, 1)
+	}
+}
+
+func (s *RetrySuite) TestServerPreferenceAndWriteFailover(c *C) {
+	var hits []string
+	handler := func(name string, status int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits = append(hits, name)
+			if status != 200 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(status)
+				return
+			}
+			w.Write([]byte(testPost))
+		})
+	}
+	primary := httptest.NewServer(handler("primary", 503))
+	defer primary.Close()
+	secondary := httptest.NewServer(handler("secondary", 200))
+	defer secondary.Close()
+
+	client := testClient([]string{secondary.URL, primary.URL})
+	client.Servers[0].Preference = 1
+	err := client.CreatePost(&Post{Type: "https://tent.io/types/status/v0#"})
+	c.Assert(err, IsNil)
+	c.Assert(hits, DeepEquals, []string{"primary", "secondary"})
+
+	// the primary is in its cool-down period and is skipped
+	hits = nil
+	err = client.CreatePost(&Post{Type: "https://tent.io/types/status/v0#"})
+	c.Assert(err, IsNil)
+	c.Assert(hits, DeepEquals, []string{"secondary"})
+}
+
+func (s *RetrySuite) TestParseRetryAfter(c *C) {
+	now := time.Date(2013, 7, 1, 12, 0, 0, 0, time.UTC)
+	d, ok := parseRetryAfter("120", now)
+	c.Assert(ok, Equals, true)
+	c.Assert(d, Equals, 2*time.Minute)
+	d, ok = parseRetryAfter("Mon, 01 Jul 2013 12:00:30 GMT", now)
+	c.Assert(ok, Equals, true)
+	c.Assert(d, Equals, 30*time.Second)
+	_, ok = parseRetryAfter("soon", now)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *RetrySuite) TestTokenBucket(c *C) {
+	now := time.Now()
+	b := &tokenBucket{rate: 2, burst: 2, tokens: 2, last: now}
+	c.Assert(b.reserve(now), Equals, time.Duration(0))
+	c.Assert(b.reserve(now), Equals, time.Duration(0))
+	c.Assert(b.reserve(now), Equals, 500*time.Millisecond)
+	b.cancel()
+	c.Assert(b.reserve(now.Add(time.Second)), Equals, time.Duration(0))
+
+	b.pause(now.Add(time.Minute))
+	c.Assert(b.reserve(now.Add(time.Second)), Equals, 59*time.Second)
+}
+
+func (s *RetrySuite) TestRetryAfter(c *C) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(429)
+			return
+		}
+		w.Write([]byte(testPost))
+	}))
+	defer ts.Close()
+
+	err := testClient([]string{ts.URL}).CreatePost(&Post{Type: "https://tent.io/types/status/v0#"})
+	c.Assert(err, IsNil)
+	c.Assert(requests, Equals, 2)
+}
+
+func (s *RetrySuite) TestLongRetryAfter(c *C) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(503)
+			return
+		}
+		w.Write([]byte(testPost))
+	}))
+	defer ts.Close()
+
+	client := testClient([]string{ts.URL})
+	start := time.Now()
+	_, err := client.GetPost("https://example.com", "a", "", nil)
+	var resErr *ResponseError
+	c.Assert(errors.As(err, &resErr), Equals, true)
+	d, ok := resErr.RetryAfter()
+	c.Assert(ok, Equals, true)
+	c.Assert(d, Equals, 24*time.Hour)
+	c.Assert(requests, Equals, 1)
+
+	// the host is only paused for MaxBackoff
+	_, err = client.GetPost("https://example.com", "a", "", nil)
+	c.Assert(err, IsNil)
+	c.Assert(time.Since(start) < time.Second, Equals, true)
+}
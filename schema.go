@@ -0,0 +1,280 @@
+package tent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// A Schema is a JSON Schema used to validate post content. It supports
+// a subset of draft-07: type, enum, const, required, properties,
+// additionalProperties, items, pattern, minLength, maxLength, minimum,
+// maximum, exclusiveMinimum, exclusiveMaximum, minItems and maxItems.
+// Other keywords are ignored.
+type Schema struct {
+	Type                 schemaTypes        `json:"type,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Const                interface{}        `json:"const,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+
+	// set for the boolean schemas true and false
+	boolean *bool
+	// set if const is present, as it may be null
+	hasConst bool
+	pattern  *regexp.Regexp
+}
+
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = schemaTypes{s}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "true" || string(data) == "false" {
+		b := string(data) == "true"
+		*s = Schema{boolean: &b}
+		return nil
+	}
+	type schema Schema
+	if err := json.Unmarshal(data, (*schema)(s)); err != nil {
+		return err
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	_, s.hasConst = keys["const"]
+	return s.compile()
+}
+
+func (s *Schema) compile() error {
+	if s.Pattern == "" {
+		return nil
+	}
+	var err error
+	s.pattern, err = regexp.Compile(s.Pattern)
+	return err
+}
+
+func ParseSchema(data []byte) (*Schema, error) {
+	s := &Schema{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Validate validates the JSON document data against the schema, and returns
+// a *ValidationError with a JSON pointer to each invalid value.
+func (s *Schema) Validate(data []byte) error {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	return s.validateValue("", doc)
+}
+
+func (s *Schema) validateValue(path string, doc interface{}) error {
+	v := &ValidationError{}
+	s.validate(v, path, doc)
+	if len(v.Fields) > 0 {
+		return v
+	}
+	return nil
+}
+
+func (s *Schema) validate(v *ValidationError, path string, doc interface{}) {
+	add := func(format string, args ...interface{}) {
+		v.Fields = append(v.Fields, &FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if s.boolean != nil {
+		if !*s.boolean {
+			add("is not allowed")
+		}
+		return
+	}
+
+	if len(s.Type) > 0 && !s.Type.match(doc) {
+		add("must be of type %s", joinTypes(s.Type))
+		return
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if reflect.DeepEqual(e, doc) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("must be one of the enumerated values")
+		}
+	}
+	if (s.hasConst || s.Const != nil) && !reflect.DeepEqual(s.Const, doc) {
+		add("must be equal to the constant value")
+	}
+
+	switch d := doc.(type) {
+	case string:
+		n := utf8.RuneCountInString(d)
+		if s.MinLength != nil && n < *s.MinLength {
+			add("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			add("must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(d) {
+			add("must match pattern %q", s.Pattern)
+		}
+	case float64:
+		if s.Minimum != nil && d < *s.Minimum {
+			add("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && d > *s.Maximum {
+			add("must be at most %v", *s.Maximum)
+		}
+		if s.ExclusiveMinimum != nil && d <= *s.ExclusiveMinimum {
+			add("must be greater than %v", *s.ExclusiveMinimum)
+		}
+		if s.ExclusiveMaximum != nil && d >= *s.ExclusiveMaximum {
+			add("must be less than %v", *s.ExclusiveMaximum)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(d) < *s.MinItems {
+			add("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(d) > *s.MaxItems {
+			add("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range d {
+				s.Items.validate(v, path+"/"+strconv.Itoa(i), item)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := d[name]; !ok {
+				v.Fields = append(v.Fields, &FieldError{Field: path + "/" + escapePointer(name), Message: "is required"})
+			}
+		}
+		keys := make([]string, 0, len(d))
+		for k := range d {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if prop, ok := s.Properties[k]; ok {
+				prop.validate(v, path+"/"+escapePointer(k), d[k])
+			} else if s.AdditionalProperties != nil {
+				s.AdditionalProperties.validate(v, path+"/"+escapePointer(k), d[k])
+			}
+		}
+	}
+}
+
+func (t schemaTypes) match(doc interface{}) bool {
+	for _, typ := range t {
+		switch d := doc.(type) {
+		case nil:
+			if typ == "null" {
+				return true
+			}
+		case bool:
+			if typ == "boolean" {
+				return true
+			}
+		case string:
+			if typ == "string" {
+				return true
+			}
+		case float64:
+			if typ == "number" || typ == "integer" && d == math.Trunc(d) {
+				return true
+			}
+		case []interface{}:
+			if typ == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if typ == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func joinTypes(t schemaTypes) string {
+	if len(t) == 1 {
+		return t[0]
+	}
+	return fmt.Sprintf("%v", []string(t))
+}
+
+var schemas = struct {
+	sync.RWMutex
+	m map[string]*Schema
+}{m: make(map[string]*Schema)}
+
+// RegisterSchema registers the schema used to validate the content of posts
+// with the type typeURI. The fragment of typeURI is ignored.
+func RegisterSchema(typeURI string, schema *Schema) {
+	schemas.Lock()
+	schemas.m[TypeBase(typeURI)] = schema
+	schemas.Unlock()
+}
+
+func registeredSchema(typ string) *Schema {
+	schemas.RLock()
+	defer schemas.RUnlock()
+	return schemas.m[TypeBase(typ)]
+}
+
+// ValidateContent validates the post content against the schema registered for
+// the post type. The paths in the returned *ValidationError start with
+// "/content". It returns nil if no schema is registered.
+func (post *Post) ValidateContent() error {
+	s := registeredSchema(post.Type)
+	if s == nil {
+		return nil
+	}
+	var doc interface{}
+	if len(post.Content) > 0 {
+		if err := json.Unmarshal(post.Content, &doc); err != nil {
+			return &ValidationError{Fields: []*FieldError{{Field: "/content", Message: "is not valid JSON"}}}
+		}
+	}
+	return s.validateValue("/content", doc)
+}
+
+// WithSchemaValidation makes the client validate the content of posts it
+// receives and creates against their registered schemas.
+func WithSchemaValidation() ClientOption {
+	return func(client *Client) { client.validateSchemas = true }
+}
@@ -0,0 +1,110 @@
+package tent
+
+import (
+	"context"
+	"errors"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/tent/hawk-go"
+)
+
+// WithResponseVerification enables verification of the Hawk
+// Server-Authorization header and payload hash of responses to signed
+// requests. Responses that fail verification return a *ResponseError with
+// Type ErrBadServerAuth.
+func WithResponseVerification() ClientOption {
+	return func(client *Client) { client.verifyResponses = true }
+}
+
+type requestAuthKey struct{}
+
+func withRequestAuth(req *http.Request, auth *hawk.Auth) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), requestAuthKey{}, auth))
+}
+
+func requestAuth(req *http.Request) *hawk.Auth {
+	if req == nil {
+		return nil
+	}
+	auth, _ := req.Context().Value(requestAuthKey{}).(*hawk.Auth)
+	return auth
+}
+
+// verifyResponse validates the Server-Authorization header of res and wraps
+// the body so that the payload hash is checked when the body has been read to
+// the end.
+func (client *Client) verifyResponse(res *http.Response) error {
+	auth, err := client.verifyResponseHeader(res)
+	if auth == nil || err != nil {
+		return err
+	}
+	if auth.Hash == nil {
+		if res.StatusCode == http.StatusNotModified {
+			return nil
+		}
+		return newServerAuthError(errMissingPayloadHash, res)
+	}
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	res.Body = &verifiedBody{ReadCloser: res.Body, hash: auth.PayloadHash(mediaType), auth: auth, res: res}
+	return nil
+}
+
+// verifyResponseHeader checks the Server-Authorization header without
+// checking the payload hash. It returns the Auth parsed from the header, or
+// nil if the response is not verified.
+func (client *Client) verifyResponseHeader(res *http.Response) (*hawk.Auth, error) {
+	if _, ok := res.Body.(cachedBody); ok {
+		// cached and coalesced responses were verified before they were
+		// stored
+		return nil, nil
+	}
+	reqAuth := requestAuth(res.Request)
+	if !client.verifyResponses || reqAuth == nil {
+		return nil, nil
+	}
+	// the request Auth holds the hash of the request payload, which must not
+	// be mistaken for the response hash
+	auth := *reqAuth
+	auth.Hash = nil
+	if err := auth.ValidResponse(res.Header.Get("Server-Authorization")); err != nil {
+		return nil, newServerAuthError(err, res)
+	}
+	return &auth, nil
+}
+
+var (
+	errMissingPayloadHash = errors.New("missing response payload hash")
+	errInvalidPayloadHash = errors.New("invalid response payload hash")
+)
+
+func newServerAuthError(err error, res *http.Response) *ResponseError {
+	resErr := newResponseError(ErrBadServerAuth, res)
+	resErr.Err = err
+	return resErr
+}
+
+type verifiedBody struct {
+	io.ReadCloser
+	hash hash.Hash
+	auth *hawk.Auth
+	res  *http.Response
+	err  error
+}
+
+func (b *verifiedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.hash.Write(p[:n])
+	if err == io.EOF && !b.auth.ValidHash(b.hash) {
+		err = newServerAuthError(errInvalidPayloadHash, b.res)
+	}
+	if err != nil {
+		b.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,72 @@
+package tent
+
+import (
+	"context"
+	"net/http"
+)
+
+const MediaTypeServerInfo = "application/vnd.tent.server-info.v0+json"
+
+const (
+	FeatureBatch = "batch"
+)
+
+// ServerInfo describes the software running on a server and its limits.
+type ServerInfo struct {
+	Software ServerSoftware `json:"software"`
+	Features []string       `json:"features,omitempty"`
+	Limits   ServerLimits   `json:"limits"`
+
+	Server *MetaPostServer `json:"-"`
+}
+
+type ServerSoftware struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	URL     string `json:"url,omitempty"`
+}
+
+// ServerLimits holds the limits enforced by a server. Zero means that the
+// server doesn't report a limit.
+type ServerLimits struct {
+	MaxPostSize       int64 `json:"max_post_size,omitempty"`
+	MaxAttachmentSize int64 `json:"max_attachment_size,omitempty"`
+	MaxPageLimit      int   `json:"max_page_limit,omitempty"`
+	MaxBatchSize      int   `json:"max_batch_size,omitempty"`
+}
+
+func (info *ServerInfo) Supports(feature string) bool {
+	for _, f := range info.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// ServerInfo fetches the server info of each server in client.Servers. The
+// returned slice has an entry for each server, which is nil if the server
+// doesn't have a server_info URL or the request failed. The error from the
+// first failed request is returned along with the other servers' info.
+func (client *Client) ServerInfo(ctx context.Context) ([]*ServerInfo, error) {
+	ctx = withEndpoint(ctx, EndpointServerInfo)
+	infos := make([]*ServerInfo, len(client.Servers))
+	var firstErr error
+	for i := range client.Servers {
+		server := &client.Servers[i]
+		if server.URLs.ServerInfo == "" {
+			continue
+		}
+		header := make(http.Header)
+		header.Set("Accept", MediaTypeServerInfo)
+		info := &ServerInfo{Server: server}
+		if _, err := client.requestJSONURL(ctx, "GET", server.URLs.ServerInfo, header, nil, info); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		infos[i] = info
+	}
+	return infos, firstErr
+}
@@ -0,0 +1,32 @@
+package tent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "launchpad.net/gocheck"
+)
+
+type ServerInfoSuite struct{}
+
+var _ = Suite(&ServerInfoSuite{})
+
+func (s *ServerInfoSuite) TestServerInfo(c *C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Header.Get("Accept"), Equals, MediaTypeServerInfo)
+		w.Write([]byte(`{"software":{"name":"tentd","version":"0.3"},"features":["batch"],"limits":{"max_attachment_size":1024}}`))
+	}))
+	defer ts.Close()
+
+	client := testClient([]string{ts.URL, ts.URL})
+	client.Servers[0].URLs.ServerInfo = ts.URL + "/server"
+	infos, err := client.ServerInfo(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(infos, HasLen, 2)
+	c.Assert(infos[0].Software.Name, Equals, "tentd")
+	c.Assert(infos[0].Limits.MaxAttachmentSize, Equals, int64(1024))
+	c.Assert(infos[0].Supports(FeatureBatch), Equals, true)
+	c.Assert(infos[0].Server, Equals, &client.Servers[0])
+	c.Assert(infos[1], IsNil)
+}
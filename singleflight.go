@@ -0,0 +1,123 @@
+package tent
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithRequestCoalescing makes concurrent identical GET requests (same URL,
+// Accept header and credentials) share a single network call. The response
+// body is read into memory so that it can be given to every caller.
+func WithRequestCoalescing() ClientOption {
+	return func(client *Client) { client.flights = &flightGroup{calls: make(map[string]*flightCall)} }
+}
+
+// Stats holds counters about the requests made by a Client.
+type Stats struct {
+	// CoalescedRequests is the number of requests that shared the result of
+	// an identical request that was already in flight.
+	CoalescedRequests int64
+
+	// CacheHits is the number of responses served from the Cache, either
+	// directly or after the server responded with 304 Not Modified.
+	CacheHits int64
+}
+
+func (client *Client) Stats() Stats {
+	return Stats{CoalescedRequests: client.coalesced.Load(), CacheHits: client.cacheHits.Load()}
+}
+
+type flightGroup struct {
+	mtx   sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+
+	res  *http.Response
+	body []byte
+	err  error
+}
+
+// detachedContext keeps the values of a context, but not its deadline or
+// cancellation, so that a shared request outlives the caller that started it.
+type detachedContext struct{ context.Context }
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func flightKey(req *http.Request) string {
+	var id string
+	if auth := requestAuth(req); auth != nil {
+		id = auth.Credentials.ID
+	}
+	return req.URL.String() + "\n" + req.Header.Get("Accept") + "\n" + req.Header.Get("If-None-Match") + "\n" + id
+}
+
+// do calls send for req, unless an identical request is already in flight, in
+// which case the result of that request is shared. The shared request is only
+// cancelled once every caller waiting for it has gone away.
+func (g *flightGroup) do(client *Client, req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	key := flightKey(req)
+	ctx := req.Context()
+
+	g.mtx.Lock()
+	call, ok := g.calls[key]
+	if ok {
+		call.waiters++
+		client.coalesced.Add(1)
+	} else {
+		callCtx, cancel := context.WithCancel(detachedContext{ctx})
+		call = &flightCall{done: make(chan struct{}), cancel: cancel, waiters: 1}
+		g.calls[key] = call
+		go g.run(client, key, call, req.WithContext(callCtx), send)
+	}
+	g.mtx.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		g.mtx.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			call.cancel()
+		}
+		g.mtx.Unlock()
+		return nil, newRequestError(ctx.Err(), req)
+	}
+	if call.err != nil {
+		return nil, call.err
+	}
+	// the response was verified by run, each caller gets its own copy with
+	// its own request
+	res := *call.res
+	res.Request = req
+	res.Body = cachedBody{bytes.NewReader(call.body)}
+	return &res, nil
+}
+
+func (g *flightGroup) run(client *Client, key string, call *flightCall, req *http.Request, send func(*http.Request) (*http.Response, error)) {
+	defer func() {
+		g.mtx.Lock()
+		delete(g.calls, key)
+		g.mtx.Unlock()
+		call.cancel()
+		close(call.done)
+	}()
+	call.res, call.err = send(req)
+	if call.err != nil {
+		return
+	}
+	defer call.res.Body.Close()
+	if call.err = client.verifyResponse(call.res); call.err != nil {
+		return
+	}
+	call.body, call.err = client.readAll(call.res)
+}
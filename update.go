@@ -0,0 +1,114 @@
+package tent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// MaxUpdateAttempts is the number of times UpdatePost tries to apply an update
+// before giving up because of conflicts.
+var MaxUpdateAttempts = 5
+
+// A ForkError is returned by UpdatePost with fork detection enabled when the
+// server accepted the update, but another version was created from the same
+// parent, so the history of the post has diverged. The update itself
+// succeeded. Versions holds children of the parent, one of which isn't the
+// created version, so that they can be merged with MergeVersions.
+type ForkError struct {
+	Entity   string
+	Post     string
+	Versions []*PostVersion
+}
+
+func (e *ForkError) Error() string {
+	return fmt.Sprintf("tent: update of post %s/%s forked its history", e.Entity, e.Post)
+}
+
+func (e *ForkError) Is(target error) bool { return target == ErrConflict }
+
+// WithForkDetection makes UpdatePost check the children of the version it
+// updated after creating the new version, and return a *ForkError along with
+// the created post if there is more than one. This is needed for servers that
+// accept stale parents instead of reporting a conflict.
+func WithForkDetection() ClientOption {
+	return func(client *Client) { client.detectForks = true }
+}
+
+// UpdatePost creates a new version of a post. It fetches the current version,
+// calls update with a copy of it, and creates the result with the fetched
+// version as its parent. If the server reports a conflict because a new
+// version was created in the meantime, the post is fetched again and update is
+// called with the new version, up to MaxUpdateAttempts times.
+func (client *Client) UpdatePost(ctx context.Context, entity, id string, update func(*Post) error) (*Post, error) {
+	var lastErr error
+	for i := 0; i < MaxUpdateAttempts; i++ {
+		env, err := client.GetPostContext(ctx, entity, id, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		post, err := updatedPost(env.Post)
+		if err != nil {
+			return nil, err
+		}
+		if err := update(post); err != nil {
+			return nil, err
+		}
+		post.ID, post.Entity = id, entity
+		if post.Version == nil || len(post.Version.Parents) == 0 {
+			post.Version = &PostVersion{Parents: []PostVersionParent{{Version: env.Post.Version.ID}}}
+		}
+
+		err = client.CreatePostContext(ctx, post)
+		if err == nil {
+			if client.detectForks {
+				return post, client.checkFork(ctx, entity, id, post, env.Post.Version.ID)
+			}
+			return post, nil
+		}
+		if !errors.Is(err, ErrConflict) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// checkFork returns a *ForkError if a version other than post was created from
+// parent. As the update has already been created, a failure to fetch the
+// children is not reported.
+func (client *Client) checkFork(ctx context.Context, entity, id string, post *Post, parent string) error {
+	if post.Version == nil {
+		return nil
+	}
+	// two children are enough to find another one
+	page, err := client.GetChildrenContext(ctx, entity, id, parent, &PageRequest{Limit: 2})
+	if err != nil {
+		return nil
+	}
+	for _, v := range page.Versions {
+		if v.ID != post.Version.ID {
+			return &ForkError{Entity: entity, Post: id, Versions: page.Versions}
+		}
+	}
+	return nil
+}
+
+// updatedPost returns a copy of head to be used as the next version.
+func updatedPost(head *Post) (*Post, error) {
+	if head.Version == nil || head.Version.ID == "" {
+		return nil, newResponseError(ErrBadData, nil)
+	}
+	data, err := json.Marshal(head)
+	if err != nil {
+		return nil, err
+	}
+	post := &Post{}
+	if err := json.Unmarshal(data, post); err != nil {
+		return nil, err
+	}
+	post.ReceivedAt = nil
+	post.Version = &PostVersion{Parents: []PostVersionParent{{Version: head.Version.ID}}}
+	return post, nil
+}
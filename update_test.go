@@ -0,0 +1,117 @@
+package tent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+
+	. "launchpad.net/gocheck"
+)
+
+type UpdateSuite struct{}
+
+var _ = Suite(&UpdateSuite{})
+
+func (s *UpdateSuite) TestUpdatePost(c *C) {
+	var puts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			version := "v1"
+			if atomic.LoadInt32(&puts) > 0 {
+				version = "v2"
+			}
+			w.Write([]byte(`{"post":{"id":"a","type":"https://tent.io/types/status/v0#","content":{"text":"hi"},"version":{"id":"` + version + `"}}}`))
+			return
+		}
+		post := &Post{}
+		c.Assert(json.NewDecoder(r.Body).Decode(post), IsNil)
+		if atomic.AddInt32(&puts, 1) == 1 {
+			c.Assert(post.Version.Parents[0].Version, Equals, "v1")
+			w.WriteHeader(409)
+			return
+		}
+		c.Assert(post.Version.Parents[0].Version, Equals, "v2")
+		c.Assert(string(post.Content), Equals, `{"text":"bye"}`)
+		post.ID, post.Version.ID = "a", "v3"
+		json.NewEncoder(w).Encode(&PostEnvelope{Post: post})
+	}))
+	defer ts.Close()
+
+	client := testClient([]string{ts.URL})
+	post, err := client.UpdatePost(context.Background(), "https://example.com", "a", func(p *Post) error {
+		return p.SetContent(&Status{Text: "bye"})
+	})
+	c.Assert(err, IsNil)
+	c.Assert(post.Version.ID, Equals, "v3")
+	c.Assert(atomic.LoadInt32(&puts), Equals, int32(2))
+}
+
+func (s *UpdateSuite) TestUpdatePostFork(c *C) {
+	var childrenFail bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("Accept") == MediaTypePostChildren:
+			c.Assert(r.URL.Query().Get("version"), Equals, "v1")
+			if childrenFail {
+				w.WriteHeader(500)
+				return
+			}
+			// v2 was created from v1 by another client
+			w.Write([]byte(`{"versions":[{"id":"v3","parents":[{"version":"v1"}]},{"id":"v2","parents":[{"version":"v1"}]}]}`))
+		case r.Method == "GET":
+			w.Write([]byte(`{"post":{"id":"a","type":"https://tent.io/types/status/v0#","content":{"text":"hi"},"version":{"id":"v1"}}}`))
+		default:
+			post := &Post{}
+			c.Assert(json.NewDecoder(r.Body).Decode(post), IsNil)
+			post.ID, post.Version.ID = "a", "v3"
+			json.NewEncoder(w).Encode(&PostEnvelope{Post: post})
+		}
+	}))
+	defer ts.Close()
+
+	update := func(p *Post) error { return p.SetContent(&Status{Text: "bye"}) }
+	client := testClient([]string{ts.URL})
+	post, err := client.UpdatePost(context.Background(), "https://example.com", "a", update)
+	c.Assert(err, IsNil)
+	c.Assert(post.Version.ID, Equals, "v3")
+
+	client = testClient([]string{ts.URL}, WithForkDetection())
+	post, err = client.UpdatePost(context.Background(), "https://example.com", "a", update)
+	c.Assert(errors.Is(err, ErrConflict), Equals, true)
+	forkErr, ok := err.(*ForkError)
+	c.Assert(ok, Equals, true)
+	c.Assert(forkErr.Versions, HasLen, 2)
+	c.Assert(post.Version.ID, Equals, "v3")
+
+	// the update succeeded even if the check fails
+	childrenFail = true
+	post, err = client.UpdatePost(context.Background(), "https://example.com", "a", update)
+	c.Assert(err, IsNil)
+	c.Assert(post.Version.ID, Equals, "v3")
+}
+
+func (s *UpdateSuite) TestUpdatePostAttemptsExhausted(c *C) {
+	var puts int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			w.Write([]byte(`{"post":{"id":"a","type":"https://tent.io/types/status/v0#","content":{"text":"hi"},"version":{"id":"v1"}}}`))
+			return
+		}
+		atomic.AddInt32(&puts, 1)
+		w.WriteHeader(409)
+	}))
+	defer ts.Close()
+
+	var updates int
+	post, err := testClient([]string{ts.URL}).UpdatePost(context.Background(), "https://example.com", "a", func(p *Post) error {
+		updates++
+		return nil
+	})
+	c.Assert(post, IsNil)
+	c.Assert(errors.Is(err, ErrConflict), Equals, true)
+	c.Assert(updates, Equals, MaxUpdateAttempts)
+	c.Assert(atomic.LoadInt32(&puts), Equals, int32(MaxUpdateAttempts))
+}
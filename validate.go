@@ -0,0 +1,114 @@
+package tent
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPost = errors.New("tent: invalid post")
+
+// A ValidationError lists the problems found with a post before it was sent.
+// The field names are JSON pointers into the post, for example
+// "/mentions/0/entity".
+type ValidationError struct {
+	Fields []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return "tent: invalid post: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool { return target == ErrInvalidPost }
+
+func (e *ValidationError) FieldErrors() []*FieldError { return e.Fields }
+
+// WithPostValidation makes CreatePost validate posts with Post.Validate before
+// sending them.
+func WithPostValidation() ClientOption {
+	return func(client *Client) { client.validatePosts = true }
+}
+
+// Validate checks that the post is well formed according to the Tent post
+// schema, and that the content matches the schema registered for its type. It
+// returns a *ValidationError listing every problem found.
+func (post *Post) Validate() error {
+	v := &ValidationError{}
+	add := func(field, msg string) { v.Fields = append(v.Fields, &FieldError{Field: field, Message: msg}) }
+
+	if post.Type == "" {
+		add("/type", "is required")
+	} else if !validTypeURI(post.Type) {
+		add("/type", "must be an absolute URI with a fragment")
+	}
+
+	public := post.Permissions.Public()
+	for i, m := range post.Mentions {
+		field := "/mentions/" + strconv.Itoa(i)
+		if m.Entity == "" && m.Post == "" {
+			add(field, "must have an entity or post")
+		}
+		if m.Version != "" && m.Post == "" {
+			add(field+"/post", "is required when version is set")
+		}
+		if m.Type != "" && !validTypeURI(m.Type) {
+			add(field+"/type", "must be an absolute URI with a fragment")
+		}
+		if !public && m.PublicFlag != nil && *m.PublicFlag {
+			add(field+"/public", "can't be public in a post that isn't public")
+		}
+	}
+	for i, r := range post.Refs {
+		field := "/refs/" + strconv.Itoa(i)
+		if r.Post == "" {
+			add(field+"/post", "is required")
+		}
+		if r.Type != "" && !validTypeURI(r.Type) {
+			add(field+"/type", "must be an absolute URI with a fragment")
+		}
+	}
+
+	if p := post.Permissions; p != nil && p.PublicFlag != nil && *p.PublicFlag && (len(p.Groups) > 0 || len(p.Entities) > 0) {
+		add("/permissions", "public posts can't be limited to groups or entities")
+	}
+
+	if post.Version != nil {
+		for i, p := range post.Version.Parents {
+			if p.Version == "" {
+				add("/version/parents/"+strconv.Itoa(i)+"/version", "is required")
+			}
+		}
+	}
+
+	for i, att := range post.Attachments {
+		field := "/attachments/" + strconv.Itoa(i)
+		if att.Category == "" {
+			add(field+"/category", "is required")
+		}
+		if att.Name == "" {
+			add(field+"/name", "is required")
+		}
+		if att.ContentType == "" {
+			add(field+"/content_type", "is required")
+		}
+	}
+
+	if err, ok := post.ValidateContent().(*ValidationError); ok {
+		v.Fields = append(v.Fields, err.Fields...)
+	}
+
+	if len(v.Fields) > 0 {
+		return v
+	}
+	return nil
+}
+
+func validTypeURI(typ string) bool {
+	u, err := url.Parse(typ)
+	return err == nil && u.IsAbs() && u.Host != "" && strings.Contains(typ, "#")
+}
@@ -0,0 +1,104 @@
+package tent
+
+import (
+	"context"
+	"errors"
+
+	. "launchpad.net/gocheck"
+)
+
+type ValidateSuite struct{}
+
+var _ = Suite(&ValidateSuite{})
+
+func (s *ValidateSuite) TestValidate(c *C) {
+	c.Assert(NewStatusPost(&Status{Text: "hi"}, nil).Validate(), IsNil)
+
+	private := false
+	public := true
+	post := &Post{
+		Type:        "status",
+		Mentions:    []PostMention{{PublicFlag: &public}},
+		Refs:        []PostRef{{Entity: "https://example.com"}},
+		Permissions: &PostPermissions{PublicFlag: &private},
+		Version:     &PostVersion{Parents: []PostVersionParent{{Post: "a"}}},
+		Attachments: []*PostAttachment{{Name: "a.png"}},
+	}
+	err := post.Validate()
+	c.Assert(errors.Is(err, ErrInvalidPost), Equals, true)
+	fields := make([]string, 0)
+	for _, f := range err.(*ValidationError).FieldErrors() {
+		fields = append(fields, f.Field)
+	}
+	c.Assert(fields, DeepEquals, []string{
+		"/type",
+		"/mentions/0",
+		"/mentions/0/public",
+		"/refs/0/post",
+		"/version/parents/0/version",
+		"/attachments/0/category",
+		"/attachments/0/content_type",
+	})
+
+	client := NewPublicClient(WithPostValidation())
+	c.Assert(errors.Is(client.CreatePost(post), ErrInvalidPost), Equals, true)
+
+	results, err := client.Batch().CreatePost(post).Send(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(errors.Is(results[0].Err, ErrInvalidPost), Equals, true)
+}
+
+func (s *ValidateSuite) TestSchema(c *C) {
+	schema, err := ParseSchema([]byte(`{
+		"type": "object",
+		"required": ["title", "rating"],
+		"additionalProperties": false,
+		"properties": {
+			"title": {"type": "string", "minLength": 1, "pattern": "^[A-Z]"},
+			"rating": {"type": "integer", "minimum": 1, "maximum": 5},
+			"tags": {"type": "array", "maxItems": 2, "items": {"enum": ["a", "b"]}},
+			"a/b": {"type": ["string", "null"]}
+		}
+	}`))
+	c.Assert(err, IsNil)
+	c.Assert(schema.Validate([]byte(`{"title":"Hi","rating":3,"tags":["a"],"a/b":null}`)), IsNil)
+
+	err = schema.Validate([]byte(`{"title":"hi","rating":3.5,"tags":["a","c","b"],"a/b":1,"extra":true}`))
+	c.Assert(errors.Is(err, ErrInvalidPost), Equals, true)
+	fields := make([]string, 0)
+	for _, f := range err.(*ValidationError).Fields {
+		fields = append(fields, f.Field+" "+f.Message)
+	}
+	c.Assert(fields, DeepEquals, []string{
+		`/a~1b must be of type [string null]`,
+		`/extra is not allowed`,
+		`/rating must be of type integer`,
+		`/tags must have at most 2 items`,
+		`/tags/1 must be one of the enumerated values`,
+		`/title must match pattern "^[A-Z]"`,
+	})
+
+	RegisterSchema("https://example.com/types/review/v0#", schema)
+	post := &Post{Type: "https://example.com/types/review/v0#", Content: []byte(`{"title":"Hi"}`)}
+	err = post.ValidateContent()
+	c.Assert(err, NotNil)
+	c.Assert(err.(*ValidationError).Fields[0].Field, Equals, "/content/rating")
+	// schemas are only checked on create when enabled
+	c.Assert(NewPublicClient().CreatePost(post), Equals, ErrNoServers)
+	client := NewPublicClient(WithSchemaValidation())
+	c.Assert(errors.Is(client.CreatePost(post), ErrInvalidPost), Equals, true)
+
+	results, err := client.Batch().CreatePost(post).Send(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(errors.Is(results[0].Err, ErrInvalidPost), Equals, true)
+}
+
+func (s *ValidateSuite) TestSchemaConstNull(c *C) {
+	schema, err := ParseSchema([]byte(`{"properties": {"a": {"const": null}}}`))
+	c.Assert(err, IsNil)
+	c.Assert(schema.Validate([]byte(`{"a":null}`)), IsNil)
+	c.Assert(schema.Validate([]byte(`{}`)), IsNil)
+	err = schema.Validate([]byte(`{"a":1}`))
+	c.Assert(err, NotNil)
+	c.Assert(err.(*ValidationError).Fields[0].Field, Equals, "/a")
+}
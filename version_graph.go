@@ -0,0 +1,169 @@
+package tent
+
+import (
+	"context"
+	"sort"
+)
+
+// A VersionGraph is the history of a post as a directed acyclic graph of its
+// versions, linked by their parents.
+type VersionGraph struct {
+	Versions map[string]*PostVersion
+
+	post     string
+	children map[string][]string
+}
+
+func (client *Client) VersionGraph(entity, post string) (*VersionGraph, error) {
+	return client.VersionGraphContext(context.Background(), entity, post)
+}
+
+// VersionGraphContext fetches every page of versions of the post and builds
+// the version graph.
+func (client *Client) VersionGraphContext(ctx context.Context, entity, post string) (*VersionGraph, error) {
+	page, err := client.GetVersionsContext(ctx, entity, post, nil)
+	if err != nil {
+		return nil, err
+	}
+	versions := page.Versions
+	for page.Links.Next != "" {
+		if page, err = page.NextContext(ctx); err != nil {
+			return nil, err
+		}
+		versions = append(versions, page.Versions...)
+	}
+	return NewVersionGraph(post, versions), nil
+}
+
+// NewVersionGraph builds the version graph of post from versions. Parents that
+// are versions of other posts, or that are not in versions, are ignored.
+func NewVersionGraph(post string, versions []*PostVersion) *VersionGraph {
+	g := &VersionGraph{Versions: make(map[string]*PostVersion, len(versions)), post: post, children: make(map[string][]string)}
+	for _, v := range versions {
+		g.Versions[v.ID] = v
+	}
+	for _, v := range g.Versions {
+		for _, p := range g.parents(v) {
+			g.children[p] = append(g.children[p], v.ID)
+		}
+	}
+	return g
+}
+
+func (g *VersionGraph) parents(v *PostVersion) []string {
+	var ids []string
+	for _, p := range v.Parents {
+		if p.Post != "" && p.Post != g.post {
+			continue
+		}
+		if _, ok := g.Versions[p.Version]; ok {
+			ids = append(ids, p.Version)
+		}
+	}
+	return ids
+}
+
+// Heads returns the versions that have no children, newest first.
+func (g *VersionGraph) Heads() []*PostVersion {
+	var heads []*PostVersion
+	for id, v := range g.Versions {
+		if len(g.children[id]) == 0 {
+			heads = append(heads, v)
+		}
+	}
+	sortVersions(heads)
+	return heads
+}
+
+// Diverged reports whether the history has more than one head, which happens
+// when versions are created concurrently from the same parent.
+func (g *VersionGraph) Diverged() bool {
+	return len(g.Heads()) > 1
+}
+
+// Ancestors returns every version that version descends from, newest first.
+func (g *VersionGraph) Ancestors(version string) []*PostVersion {
+	seen := g.ancestors(version)
+	delete(seen, version)
+	res := make([]*PostVersion, 0, len(seen))
+	for id := range seen {
+		res = append(res, g.Versions[id])
+	}
+	sortVersions(res)
+	return res
+}
+
+// ancestors returns the set of ancestors of version, including itself.
+func (g *VersionGraph) ancestors(version string) map[string]bool {
+	seen := make(map[string]bool)
+	if v, ok := g.Versions[version]; ok {
+		seen[version] = true
+		g.markAncestors(seen, []*PostVersion{v})
+	}
+	return seen
+}
+
+// markAncestors adds the ancestors of the versions in queue to seen, walking
+// the graph breadth first. Versions already in seen are not walked again.
+func (g *VersionGraph) markAncestors(seen map[string]bool, queue []*PostVersion) {
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, p := range g.parents(v) {
+			if !seen[p] {
+				seen[p] = true
+				queue = append(queue, g.Versions[p])
+			}
+		}
+	}
+}
+
+// IsAncestor reports whether version a is an ancestor of version b.
+func (g *VersionGraph) IsAncestor(a, b string) bool {
+	return a != b && g.ancestors(b)[a]
+}
+
+// LowestCommonAncestor returns the newest version that both a and b descend
+// from, which may be a or b itself. It returns nil if they have no common
+// ancestor.
+func (g *VersionGraph) LowestCommonAncestor(a, b string) *PostVersion {
+	ancestorsA, ancestorsB := g.ancestors(a), g.ancestors(b)
+	var common []*PostVersion
+	for id := range ancestorsA {
+		if ancestorsB[id] {
+			common = append(common, g.Versions[id])
+		}
+	}
+	// the ancestors of a common ancestor are common ancestors too, the lowest
+	// ones are those that aren't reached by walking up from the others
+	notLowest := make(map[string]bool)
+	g.markAncestors(notLowest, append([]*PostVersion(nil), common...))
+	var lowest []*PostVersion
+	for _, v := range common {
+		if !notLowest[v.ID] {
+			lowest = append(lowest, v)
+		}
+	}
+	if len(lowest) == 0 {
+		return nil
+	}
+	sortVersions(lowest)
+	return lowest[0]
+}
+
+func sortVersions(versions []*PostVersion) {
+	sort.Slice(versions, func(i, j int) bool {
+		a, b := versions[i], versions[j]
+		var ta, tb int64
+		if a.PublishedAt != nil {
+			ta = a.PublishedAt.UnixMillis()
+		}
+		if b.PublishedAt != nil {
+			tb = b.PublishedAt.UnixMillis()
+		}
+		if ta != tb {
+			return ta > tb
+		}
+		return a.ID < b.ID
+	})
+}
@@ -0,0 +1,63 @@
+package tent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "launchpad.net/gocheck"
+)
+
+type VersionGraphSuite struct{}
+
+var _ = Suite(&VersionGraphSuite{})
+
+func (s *VersionGraphSuite) TestVersionGraph(c *C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Header.Get("Accept"), Equals, MediaTypePostVersions)
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`{"versions":[{"id":"a","published_at":1}]}`))
+			return
+		}
+		w.Write([]byte(`{"versions":[
+			{"id":"c","published_at":3,"parents":[{"version":"a"}]},
+			{"id":"b","published_at":2,"parents":[{"version":"a"},{"post":"other","version":"x"}]}
+		],"pages":{"next":"?page=2"}}`))
+	}))
+	defer ts.Close()
+
+	g, err := testClient([]string{ts.URL}).VersionGraph("https://example.com", "p")
+	c.Assert(err, IsNil)
+	c.Assert(g.Versions, HasLen, 3)
+	heads := g.Heads()
+	c.Assert(heads, HasLen, 2)
+	c.Assert(heads[0].ID, Equals, "c")
+	c.Assert(heads[1].ID, Equals, "b")
+	c.Assert(g.Diverged(), Equals, true)
+	c.Assert(g.LowestCommonAncestor("b", "c").ID, Equals, "a")
+	c.Assert(g.LowestCommonAncestor("a", "c").ID, Equals, "a")
+	c.Assert(g.Ancestors("b"), HasLen, 1)
+	c.Assert(g.IsAncestor("a", "b"), Equals, true)
+	c.Assert(g.IsAncestor("b", "c"), Equals, false)
+}
+
+func (s *VersionGraphSuite) TestLowestCommonAncestor(c *C) {
+	// d and e both merge b and c, so b and c are both lowest common
+	// ancestors and the newest one is returned
+	var versions []*PostVersion
+	c.Assert(json.Unmarshal([]byte(`[
+		{"id":"a","published_at":1},
+		{"id":"b","published_at":2,"parents":[{"version":"a"}]},
+		{"id":"c","published_at":3,"parents":[{"version":"a"}]},
+		{"id":"d","published_at":4,"parents":[{"version":"b"},{"version":"c"}]},
+		{"id":"e","published_at":5,"parents":[{"version":"c"},{"version":"b"}]},
+		{"id":"f","published_at":6,"parents":[{"version":"d"}]},
+		{"id":"x","published_at":7}
+	]`), &versions), IsNil)
+	g := NewVersionGraph("p", versions)
+	c.Assert(g.LowestCommonAncestor("f", "e").ID, Equals, "c")
+	c.Assert(g.LowestCommonAncestor("f", "b").ID, Equals, "b")
+	c.Assert(g.LowestCommonAncestor("d", "d").ID, Equals, "d")
+	c.Assert(g.LowestCommonAncestor("f", "x"), IsNil)
+	c.Assert(g.LowestCommonAncestor("f", "missing"), IsNil)
+}